@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// animateFlags - command-line flags for the scan-progression animation.
+type animateFlags struct {
+	path   *string
+	frames *int
+	delay  *int
+	scale  *float64
+}
+
+func addAnimateFlags(fs *flag.FlagSet) animateFlags {
+	d := imageutil.DefaultAnimationOptions
+	return animateFlags{
+		path:   fs.String("animate", "", "write the row-by-row scan as an animation to this file: .gif or .png/.apng (animated PNG)"),
+		frames: fs.Int("animate-frames", d.Frames, "-animate number of frames, the finished result included"),
+		delay:  fs.Int("animate-delay", d.Delay, "-animate time per frame, in milliseconds"),
+		scale:  fs.Float64("animate-scale", d.Scale, "-animate frame size relative to the image"),
+	}
+}
+
+// write renders the scan of binImg and saves the animation when -animate
+// is set.
+func (f animateFlags) write(ctx context.Context, binImg image.Image) error {
+	if *f.path == "" {
+		return nil
+	}
+	var write func(io.Writer, []*image.Paletted, int) error
+	switch strings.ToLower(filepath.Ext(*f.path)) {
+	case ".gif":
+		write = imageutil.WriteGIFAnimation
+	case ".png", ".apng":
+		write = imageutil.WriteAPNG
+	default:
+		return fmt.Errorf("-animate %q: want a .gif, .png or .apng file", *f.path)
+	}
+	if binImg == nil {
+		return fmt.Errorf("-animate needs a binary mask, which this segmentation mode does not produce")
+	}
+	if *f.delay < 0 {
+		return fmt.Errorf("invalid -animate-delay %d", *f.delay)
+	}
+	frames, err := imageutil.ScanFrames(ctx, binImg, imageutil.AnimationOptions{
+		Frames: *f.frames,
+		Delay:  *f.delay,
+		Scale:  *f.scale,
+	})
+	if err != nil {
+		return err
+	}
+	return writeFile(*f.path, func(w io.Writer) error { return write(w, frames, *f.delay) })
+}
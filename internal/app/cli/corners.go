@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// cornerFlags - command-line flags for corner and inflection detection.
+type cornerFlags struct {
+	markers *bool
+	path    *string
+	angle   *float64
+	span    *int
+	sigma   *float64
+}
+
+func addCornerFlags(fs *flag.FlagSet) cornerFlags {
+	d := imageutil.DefaultCornerOptions
+	return cornerFlags{
+		markers: fs.Bool("corners", false, "mark corners (with their angle) and inflection points in the output image"),
+		path:    fs.String("corners-csv", "", "write the corners and inflection points of every contour as CSV to this file"),
+		angle:   fs.Float64("corner-angle", d.MaxAngle, "angles sharper than this (degrees) count as corners"),
+		span:    fs.Int("corner-span", d.Span, "contour points on each side used to measure corner angles"),
+		sigma:   fs.Float64("corner-sigma", d.Sigma, "Gaussian smoothing of the curvature for inflection points, in contour points"),
+	}
+}
+
+// apply writes the corners file and draws the markers onto outImg if
+// requested; otherwise it returns outImg unchanged.
+func (f cornerFlags) apply(ctx context.Context, binImg, outImg image.Image) (image.Image, error) {
+	if !*f.markers && *f.path == "" {
+		return outImg, nil
+	}
+	if binImg == nil {
+		return nil, fmt.Errorf("corner detection needs a binary mask, which this segmentation mode does not produce")
+	}
+	if *f.span < 1 || *f.angle <= 0 || *f.angle > 180 {
+		return nil, fmt.Errorf("invalid -corner-span %d or -corner-angle %g", *f.span, *f.angle)
+	}
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return nil, err
+	}
+	opts := imageutil.DefaultCornerOptions
+	opts.MaxAngle, opts.Span, opts.Sigma = *f.angle, *f.span, *f.sigma
+	cs, err := imageutil.AnalyzeCorners(ctx, contours, opts)
+	if err != nil {
+		return nil, err
+	}
+
+	if *f.path != "" {
+		out, err := os.Create(*f.path)
+		if err != nil {
+			return nil, err
+		}
+		defer out.Close()
+		if err := imageutil.WriteCornersCSV(out, cs); err != nil {
+			return nil, err
+		}
+	}
+
+	if *f.markers {
+		return imageutil.DrawCorners(ctx, outImg, cs)
+	}
+	return outImg, nil
+}
@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// cropFlags - command-line flags for saving every object as its own image.
+type cropFlags struct {
+	tmpl     *string
+	padding  *int
+	mask     *bool
+	minArea  *float64
+	manifest *string
+}
+
+func addCropFlags(fs *flag.FlagSet) cropFlags {
+	return cropFlags{
+		tmpl:     fs.String("crops", "", "save every object cropped from the original to files named by this template, e.g. crops/{name}_{id}_{area}.png: {name} - input base name, {id} - contour ID, {n} - object number, {area} - area in pixels, {shape} - shape class; needs {id} or {n}"),
+		padding:  fs.Int("crop-pad", 0, "-crops pixels added around every bounding box, 0 or more"),
+		mask:     fs.Bool("crop-mask", false, "-crops with everything outside the object made transparent (use .png)"),
+		minArea:  fs.Float64("crop-min-area", 0, "-crops skips objects with a smaller area, in square pixels"),
+		manifest: fs.String("crop-manifest", "", "-crops list of the files with their source coordinates and metrics, written as .json or .csv"),
+	}
+}
+
+// write saves the object crops of img and their manifest when -crops is set.
+func (f cropFlags) write(ctx context.Context, inPath string, img, binImg image.Image) error {
+	if *f.tmpl == "" {
+		if *f.manifest != "" {
+			return fmt.Errorf("-crop-manifest needs -crops")
+		}
+		return nil
+	}
+	if err := checkNameKeys("crops", *f.tmpl, "id", "n"); err != nil {
+		return err
+	}
+	if *f.padding < 0 {
+		return fmt.Errorf("invalid -crop-pad %d (want 0 or more)", *f.padding)
+	}
+	if binImg == nil {
+		return fmt.Errorf("-crops needs a binary mask, which this segmentation mode does not produce")
+	}
+	manifestFormat := strings.TrimPrefix(strings.ToLower(filepath.Ext(*f.manifest)), ".")
+	if *f.manifest != "" && manifestFormat != "json" && manifestFormat != "csv" {
+		return fmt.Errorf("-crop-manifest %q: want a .json or .csv file", *f.manifest)
+	}
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return err
+	}
+	crops, err := imageutil.CropObjects(ctx, img, contours, imageutil.CropOptions{
+		Padding: *f.padding,
+		Mask:    *f.mask,
+		MinArea: *f.minArea,
+	})
+	if err != nil {
+		return err
+	}
+
+	base := strings.TrimSuffix(filepath.Base(inPath), filepath.Ext(inPath))
+	records := make([]imageutil.CropRecord, len(crops))
+	for i, c := range crops {
+		name := expandName(*f.tmpl, map[string]string{
+			"name":  base,
+			"id":    strconv.Itoa(c.Metrics.ID),
+			"n":     strconv.Itoa(i + 1),
+			"area":  strconv.Itoa(int(math.Round(c.Metrics.Area))),
+			"shape": string(c.Metrics.Shape),
+		})
+		if err := os.MkdirAll(filepath.Dir(name), 0o755); err != nil {
+			return err
+		}
+		if err := writeImage(name, c.Image); err != nil {
+			return err
+		}
+		records[i] = imageutil.NewCropRecord(name, c)
+	}
+	fmt.Printf("Crops: %d\n", len(crops))
+
+	if *f.manifest == "" {
+		return nil
+	}
+	return writeFile(*f.manifest, func(w io.Writer) error {
+		return imageutil.WriteCropManifest(w, inPath, records, manifestFormat)
+	})
+}
@@ -0,0 +1,63 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// cutoutFlags - command-line flags for the object mask and the cut-out.
+type cutoutFlags struct {
+	mask    *string
+	cutout  *string
+	feather *float64
+}
+
+func addCutoutFlags(fs *flag.FlagSet) cutoutFlags {
+	return cutoutFlags{
+		mask:    fs.String("mask", "", "write the filled objects as a mask (white objects on black) to this image file"),
+		cutout:  fs.String("cutout", "", "write the original with the background made transparent to this image file (use .png)"),
+		feather: fs.Float64("feather", 0, "-mask and -cutout edge softness, Gaussian sigma in pixels; 0 - hard edge"),
+	}
+}
+
+// write fills the contours of binImg and saves the mask and the cut-out
+// of img if requested.
+func (f cutoutFlags) write(ctx context.Context, img, binImg image.Image) error {
+	if *f.mask == "" && *f.cutout == "" {
+		return nil
+	}
+	if binImg == nil {
+		return fmt.Errorf("-mask and -cutout need a binary mask, which this segmentation mode does not produce")
+	}
+	if *f.feather < 0 {
+		return fmt.Errorf("invalid -feather %g", *f.feather)
+	}
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return err
+	}
+	alpha, err := imageutil.ContourMask(ctx, binImg.Bounds(), contours, *f.feather)
+	if err != nil {
+		return err
+	}
+
+	if *f.mask != "" {
+		if err := writeImage(*f.mask, imageutil.MaskImage(alpha)); err != nil {
+			return err
+		}
+	}
+	if *f.cutout != "" {
+		cut, err := imageutil.Cutout(ctx, img, alpha)
+		if err != nil {
+			return err
+		}
+		if err := writeImage(*f.cutout, cut); err != nil {
+			return err
+		}
+	}
+	return nil
+}
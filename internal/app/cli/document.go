@@ -0,0 +1,99 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// runDocument executes the "cli document" subcommand: find the page border,
+// correct the perspective and write the flattened page.
+func runDocument(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("document", flag.ExitOnError)
+	inPath := fs.String("in", "", "input photo of the document (required)")
+	outPath := fs.String("out", "page.png", "flattened page output file (format inferred from extension)")
+	interpName := fs.String("interp", "bicubic", "resampling: bilinear|bicubic")
+	cornersArg := fs.String("corners", "", "page corners x1,y1,...,x4,y4 (top-left first, clockwise); detected if empty")
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s cli document [flags]\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintln(os.Stderr, "Flags for cli document command:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *inPath == "" {
+		fs.Usage()
+		return fmt.Errorf("\nError: flag -in is required")
+	}
+
+	interp, err := imageutil.ParseInterpolation(*interpName)
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(*inPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return err
+	}
+
+	var q imageutil.Quad
+	if *cornersArg != "" {
+		q, err = parseQuad(*cornersArg)
+	} else {
+		q, err = imageutil.DetectDocument(ctx, img)
+	}
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Corners: %s\n", formatQuad(q))
+
+	page, err := imageutil.FlattenDocument(ctx, img, q, interp)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Output: %s (%dx%d)\n", *outPath, page.Bounds().Dx(), page.Bounds().Dy())
+	return writeImage(*outPath, page)
+}
+
+// parseQuad parses "x1,y1,x2,y2,x3,y3,x4,y4".
+func parseQuad(s string) (imageutil.Quad, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 8 {
+		return imageutil.Quad{}, fmt.Errorf("invalid corners %q: want 8 comma-separated numbers", s)
+	}
+	var q imageutil.Quad
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return imageutil.Quad{}, fmt.Errorf("invalid corners %q: %w", s, err)
+		}
+		if i%2 == 0 {
+			q[i/2].X = v
+		} else {
+			q[i/2].Y = v
+		}
+	}
+	return q, nil
+}
+
+func formatQuad(q imageutil.Quad) string {
+	var parts []string
+	for _, p := range q {
+		parts = append(parts, fmt.Sprintf("%.1f,%.1f", p.X, p.Y))
+	}
+	return strings.Join(parts, ",")
+}
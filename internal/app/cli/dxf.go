@@ -0,0 +1,55 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// dxfFlags - command-line flags for -out *.dxf.
+type dxfFlags struct {
+	scale    *float64
+	units    *string
+	simplify *float64
+}
+
+func addDXFFlags(fs *flag.FlagSet) dxfFlags {
+	return dxfFlags{
+		scale:    fs.Float64("dxf-scale", imageutil.DefaultDXFOptions.Scale, "-out *.dxf: drawing units per pixel (e.g. 0.0847 mm for 300 dpi)"),
+		units:    fs.String("dxf-units", "mm", "-out *.dxf: drawing units in the header: mm|cm|m|in|none"),
+		simplify: fs.Float64("dxf-simplify", 0, "-out *.dxf: merge points within this many pixels of a straight line (0 keeps every pixel)"),
+	}
+}
+
+// write saves the contours of the binary mask as DXF polylines.
+func (f dxfFlags) write(ctx context.Context, path string, binImg image.Image) error {
+	if binImg == nil {
+		return fmt.Errorf("DXF output needs a binary mask, which this segmentation mode does not produce")
+	}
+	units, err := imageutil.ParseDXFUnits(*f.units)
+	if err != nil {
+		return err
+	}
+	if *f.scale <= 0 || *f.simplify < 0 {
+		return fmt.Errorf("invalid -dxf-scale %g or -dxf-simplify %g", *f.scale, *f.simplify)
+	}
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return err
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return imageutil.WriteDXF(ctx, out, binImg.Bounds(), contours, imageutil.DXFOptions{
+		Scale:    *f.scale,
+		Units:    units,
+		Simplify: *f.simplify,
+	})
+}
@@ -0,0 +1,111 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// runFlatbed executes the "cli flatbed" subcommand: split a scan of several
+// photos into one straightened file per photo.
+func runFlatbed(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("flatbed", flag.ExitOnError)
+	inPath := fs.String("in", "", "input flatbed scan (required)")
+	outTmpl := fs.String("out", "{name}_{n}.png", "output file name template: {name} - input base name, {n} - object number (required)")
+	minSize := fs.Int("min-size", imageutil.DefaultFlatbedOptions.MinSize, "skip objects smaller than this on either side, in pixels")
+	padding := fs.Int("pad", 0, "pixels added around every object; negative values trim the edges")
+	interpName := fs.String("interp", "bicubic", "resampling: bilinear|bicubic")
+	segFlags := addSegmentFlags(fs)
+
+	fs.Usage = func() {
+		fmt.Fprintf(os.Stderr, "Usage: %s cli flatbed [flags]\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintln(os.Stderr, "Flags for cli flatbed command:")
+		fs.PrintDefaults()
+	}
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *inPath == "" {
+		fs.Usage()
+		return fmt.Errorf("\nError: flag -in is required")
+	}
+	if err := checkNameKeys("out", *outTmpl, "n"); err != nil {
+		return err
+	}
+
+	interp, err := imageutil.ParseInterpolation(*interpName)
+	if err != nil {
+		return err
+	}
+	segOpts, err := segFlags.options()
+	if err != nil {
+		return err
+	}
+
+	src, err := os.Open(*inPath)
+	if err != nil {
+		return err
+	}
+	defer src.Close()
+	img, _, err := image.Decode(src)
+	if err != nil {
+		return err
+	}
+
+	bin, err := imageutil.Segment(ctx, img, segOpts)
+	if err != nil {
+		return err
+	}
+	objs, err := imageutil.SplitFlatbed(ctx, img, bin, imageutil.FlatbedOptions{
+		MinSize: *minSize,
+		Padding: *padding,
+		Interp:  interp,
+	})
+	if err != nil {
+		return err
+	}
+	if len(objs) == 0 {
+		return fmt.Errorf("no objects of at least %d px found", *minSize)
+	}
+
+	base := strings.TrimSuffix(filepath.Base(*inPath), filepath.Ext(*inPath))
+	for i, o := range objs {
+		name := expandName(*outTmpl, map[string]string{
+			"name": base,
+			"n":    strconv.Itoa(i + 1),
+		})
+		b := o.Image.Bounds()
+		fmt.Printf("Object %d: %s (%dx%d, %.1f°)\n", i+1, name, b.Dx(), b.Dy(), o.Box.Angle())
+		if err := writeImage(name, o.Image); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// expandName replaces the {key} placeholders of an output name template.
+func expandName(tmpl string, fields map[string]string) string {
+	var pairs []string
+	for k, v := range fields {
+		pairs = append(pairs, "{"+k+"}", v)
+	}
+	return strings.NewReplacer(pairs...).Replace(tmpl)
+}
+
+// checkNameKeys makes sure the template of flag name contains one of the
+// placeholders keys, so that every object gets its own file.
+func checkNameKeys(name, tmpl string, keys ...string) error {
+	for _, k := range keys {
+		if strings.Contains(tmpl, "{"+k+"}") {
+			return nil
+		}
+	}
+	return fmt.Errorf("-%s template %q needs {%s}, or every object overwrites the last", name, tmpl, strings.Join(keys, "} or {"))
+}
@@ -0,0 +1,24 @@
+package cli
+
+import "testing"
+
+func TestCheckNameKeys(t *testing.T) {
+	tests := []struct {
+		tmpl string
+		keys []string
+		ok   bool
+	}{
+		{"{name}_{n}.png", []string{"n"}, true},
+		{"out/x.png", []string{"n"}, false},
+		{"{name}.png", []string{"n"}, false},
+		{"crops/{id}.png", []string{"id", "n"}, true},
+		{"crops/{n}.png", []string{"id", "n"}, true},
+		{"crops/{name}_{area}.png", []string{"id", "n"}, false},
+	}
+	for _, tt := range tests {
+		err := checkNameKeys("out", tt.tmpl, tt.keys...)
+		if (err == nil) != tt.ok {
+			t.Errorf("%q with %v: got error %v, want ok %v", tt.tmpl, tt.keys, err, tt.ok)
+		}
+	}
+}
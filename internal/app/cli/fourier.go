@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// fourierFlags - command-line flags for elliptic Fourier descriptors.
+type fourierFlags struct {
+	path    *string
+	order   *int
+	samples *int
+	raw     *bool
+	outline *string
+	smooth  *int
+}
+
+func addFourierFlags(fs *flag.FlagSet) fourierFlags {
+	return fourierFlags{
+		path:    fs.String("efd", "", "write elliptic Fourier descriptors of every contour as CSV to this file"),
+		order:   fs.Int("efd-order", 10, "number of Fourier harmonics per contour"),
+		samples: fs.Int("efd-samples", 128, "points each contour is resampled to along its length"),
+		raw:     fs.Bool("efd-raw", false, "-efd write the raw coefficients instead of size, rotation and start-point normalised ones"),
+		outline: fs.String("efd-outline", "", "write the contours reconstructed from their first -efd-smooth harmonics to this image file"),
+		smooth:  fs.Int("efd-smooth", 5, "-efd-outline harmonics used, at least 1; fewer give smoother outlines"),
+	}
+}
+
+// write produces the requested Fourier outputs from the binary mask.
+func (f fourierFlags) write(ctx context.Context, binImg image.Image) error {
+	if *f.path == "" && *f.outline == "" {
+		return nil
+	}
+	if binImg == nil {
+		return fmt.Errorf("Fourier descriptors need a binary mask, which this segmentation mode does not produce")
+	}
+	if *f.order < 1 || *f.samples < 3 {
+		return fmt.Errorf("invalid -efd-order %d or -efd-samples %d", *f.order, *f.samples)
+	}
+	if *f.outline != "" && *f.smooth < 1 {
+		return fmt.Errorf("invalid -efd-smooth %d (want at least 1)", *f.smooth)
+	}
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return err
+	}
+	ds, err := imageutil.DescribeContours(ctx, contours, *f.samples, max(*f.order, *f.smooth))
+	if err != nil {
+		return err
+	}
+
+	if *f.outline != "" {
+		img, err := imageutil.DrawFourierOutlines(ctx, binImg.Bounds(), ds, *f.smooth)
+		if err != nil {
+			return err
+		}
+		if err := writeImage(*f.outline, img); err != nil {
+			return err
+		}
+	}
+
+	if *f.path != "" {
+		out := make([]imageutil.ContourDescriptor, len(ds))
+		for i, d := range ds {
+			d.Fourier.Harmonics = d.Fourier.Harmonics[:*f.order]
+			if !*f.raw {
+				d.Fourier = d.Fourier.Normalize()
+			}
+			out[i] = d
+		}
+		file, err := os.Create(*f.path)
+		if err != nil {
+			return err
+		}
+		defer file.Close()
+		return imageutil.WriteDescriptorsCSV(file, out)
+	}
+	return nil
+}
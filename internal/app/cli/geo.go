@@ -0,0 +1,97 @@
+package cli
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"os"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// geoFlags - command-line flags for GeoJSON and WKT output.
+type geoFlags struct {
+	geojson      *string
+	wkt          *string
+	multi        *bool
+	geotransform *string
+	worldFile    *string
+}
+
+func addGeoFlags(fs *flag.FlagSet) geoFlags {
+	return geoFlags{
+		geojson:      fs.String("geojson", "", "write the objects as a GeoJSON FeatureCollection of polygons to this file"),
+		wkt:          fs.String("wkt", "", "write the objects as WKT polygons to this file"),
+		multi:        fs.Bool("wkt-multi", false, "-wkt write one MULTIPOLYGON instead of a POLYGON per line"),
+		geotransform: fs.String("geotransform", "", "pixel to world transform as 6 numbers in GDAL order: x0,dx/px,dx/py,y0,dy/px,dy/py"),
+		worldFile:    fs.String("world-file", "", "read the transform from this world file (default: a .pgw/.tfw/... next to the input)"),
+	}
+}
+
+// write saves the polygons of the binary mask, georeferenced if a
+// transform is given or found next to the input.
+func (f geoFlags) write(ctx context.Context, inPath string, binImg image.Image) error {
+	if *f.geojson == "" && *f.wkt == "" {
+		return nil
+	}
+	if binImg == nil {
+		return fmt.Errorf("GeoJSON and WKT output need a binary mask, which this segmentation mode does not produce")
+	}
+	gt, err := f.transform(inPath)
+	if err != nil {
+		return err
+	}
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return err
+	}
+	polys := imageutil.BuildPolygons(contours, gt)
+
+	if *f.geojson != "" {
+		if err := writeFile(*f.geojson, func(w io.Writer) error { return imageutil.WriteGeoJSON(w, polys) }); err != nil {
+			return err
+		}
+	}
+	if *f.wkt != "" {
+		return writeFile(*f.wkt, func(w io.Writer) error { return imageutil.WriteWKT(w, polys, *f.multi) })
+	}
+	return nil
+}
+
+// transform picks the geotransform: -geotransform, then -world-file, then
+// a world file next to the input, else pixel coordinates.
+func (f geoFlags) transform(inPath string) (imageutil.GeoTransform, error) {
+	if *f.geotransform != "" {
+		return imageutil.ParseGeoTransform(*f.geotransform)
+	}
+	names := imageutil.WorldFileNames(inPath)
+	if *f.worldFile != "" {
+		names = []string{*f.worldFile}
+	}
+	for _, name := range names {
+		file, err := os.Open(name)
+		if errors.Is(err, os.ErrNotExist) && *f.worldFile == "" {
+			continue
+		}
+		if err != nil {
+			return imageutil.GeoTransform{}, err
+		}
+		defer file.Close()
+		fmt.Printf("Geotransform: %s\n", name)
+		return imageutil.ReadWorldFile(file)
+	}
+	return imageutil.IdentityGeoTransform, nil
+}
+
+// writeFile creates path and fills it with write.
+func writeFile(path string, write func(w io.Writer) error) error {
+	file, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return write(file)
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"path/filepath"
+	"strings"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// layerFlags - command-line flags for the layered stage output.
+type layerFlags struct {
+	path *string
+}
+
+func addLayerFlags(fs *flag.FlagSet) layerFlags {
+	return layerFlags{
+		path: fs.String("layers", "", "write the original, binarized, contour and mask stages as layers to this file: .tif/.tiff - one page each, .ora - OpenRaster; contours use the -overlay-* style"),
+	}
+}
+
+// write saves the stages of img as a multi-page TIFF or an OpenRaster
+// file when -layers is set.
+func (f layerFlags) write(ctx context.Context, img, binImg image.Image, overlay overlayFlags) error {
+	if *f.path == "" {
+		return nil
+	}
+	var write func(io.Writer, []imageutil.Layer) error
+	switch strings.ToLower(filepath.Ext(*f.path)) {
+	case ".tif", ".tiff":
+		write = imageutil.WriteMultiPageTIFF
+	case ".ora":
+		write = imageutil.WriteORA
+	default:
+		return fmt.Errorf("-layers %q: want a .tif, .tiff or .ora file", *f.path)
+	}
+	if binImg == nil {
+		return fmt.Errorf("-layers needs a binary mask, which this segmentation mode does not produce")
+	}
+	style, err := overlay.style()
+	if err != nil {
+		return err
+	}
+	layers, err := imageutil.StageLayers(ctx, img, binImg, style)
+	if err != nil {
+		return err
+	}
+	return writeFile(*f.path, func(w io.Writer) error { return write(w, layers) })
+}
@@ -0,0 +1,77 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// matchFlags - command-line flags for template shape matching.
+type matchFlags struct {
+	template  *string
+	threshold *float64
+	minPoints *int
+}
+
+func addMatchFlags(fs *flag.FlagSet) matchFlags {
+	return matchFlags{
+		template:  fs.String("match", "", "template image; its largest contour is compared with every contour of the input (not with -overlay, -shape-labels or -corners)"),
+		threshold: fs.Float64("match-threshold", imageutil.DefaultMatchThreshold, "-match minimum similarity (0-1) to highlight a contour"),
+		minPoints: fs.Int("match-min-points", 20, "-match ignore contours with fewer border points"),
+	}
+}
+
+// apply scores the contours of binImg against the template and, when
+// -match is set, returns img with the matches highlighted instead of outImg.
+func (f matchFlags) apply(ctx context.Context, img, binImg, outImg image.Image, segOpts imageutil.SegmentOptions) (image.Image, error) {
+	if *f.template == "" {
+		return outImg, nil
+	}
+	if binImg == nil {
+		return nil, fmt.Errorf("-match needs a binary mask, which this segmentation mode does not produce")
+	}
+
+	src, err := os.Open(*f.template)
+	if err != nil {
+		return nil, err
+	}
+	defer src.Close()
+	tmplImg, _, err := image.Decode(src)
+	if err != nil {
+		return nil, err
+	}
+	tmplBin, err := imageutil.Segment(ctx, tmplImg, segOpts)
+	if err != nil {
+		return nil, err
+	}
+	tmplContours, err := imageutil.FindContours(ctx, tmplBin)
+	if err != nil {
+		return nil, err
+	}
+	tmpl, ok := imageutil.LargestContour(tmplContours)
+	if !ok {
+		return nil, fmt.Errorf("no contour found in template %s", *f.template)
+	}
+
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return nil, err
+	}
+	matches, err := imageutil.MatchShapes(ctx, tmpl.Points, contours, *f.minPoints)
+	if err != nil {
+		return nil, err
+	}
+	hits := 0
+	for _, m := range matches {
+		if m.Score >= *f.threshold {
+			hits++
+			fmt.Printf("Match: contour %d score %.3f\n", m.Contour.ID, m.Score)
+		}
+	}
+	fmt.Printf("Matches: %d of %d contours\n", hits, len(matches))
+	return imageutil.DrawMatches(ctx, img, matches, *f.threshold)
+}
@@ -0,0 +1,71 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"image/color"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// metricsFlags - command-line flags for per-contour measurements.
+type metricsFlags struct {
+	path   *string
+	labels *bool
+}
+
+func addMetricsFlags(fs *flag.FlagSet) metricsFlags {
+	return metricsFlags{
+		path:   fs.String("metrics", "", "write per-contour metrics and shape classes to this file (.csv or .json)"),
+		labels: fs.Bool("shape-labels", false, "write the shape class next to every object in the output image"),
+	}
+}
+
+// apply writes the metrics file and draws the shape labels onto outImg if
+// requested; otherwise it returns outImg unchanged.
+func (f metricsFlags) apply(ctx context.Context, binImg, outImg image.Image) (image.Image, error) {
+	if *f.path == "" && !*f.labels {
+		return outImg, nil
+	}
+	if binImg == nil {
+		return nil, fmt.Errorf("contour metrics need a binary mask, which this segmentation mode does not produce")
+	}
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return nil, err
+	}
+	ms, err := imageutil.MeasureContours(ctx, contours)
+	if err != nil {
+		return nil, err
+	}
+
+	if *f.path != "" {
+		write := imageutil.WriteMetricsCSV
+		switch ext := strings.ToLower(filepath.Ext(*f.path)); ext {
+		case ".csv":
+		case ".json":
+			write = imageutil.WriteMetricsJSON
+		default:
+			return nil, fmt.Errorf("unsupported metrics format %q (want .csv or .json)", ext)
+		}
+		out, err := os.Create(*f.path)
+		if err != nil {
+			return nil, err
+		}
+		defer out.Close()
+		if err := write(out, ms); err != nil {
+			return nil, err
+		}
+	}
+
+	if *f.labels {
+		labels := imageutil.ShapeLabels(ms, outImg.Bounds())
+		return imageutil.DrawLabels(ctx, outImg, labels, color.NRGBA{B: 160, A: 255})
+	}
+	return outImg, nil
+}
@@ -0,0 +1,84 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// overlayFlags - command-line flags for drawing the contours over an image.
+type overlayFlags struct {
+	base      *string
+	color     *string
+	palette   *bool
+	thickness *float64
+	antialias *bool
+	opacity   *float64
+	fill      *float64
+	ids       *bool
+}
+
+func addOverlayFlags(fs *flag.FlagSet) overlayFlags {
+	d := imageutil.DefaultOverlayStyle
+	return overlayFlags{
+		base:      fs.String("overlay", "", "draw the contours over the original or mask image instead of on white: original|mask"),
+		color:     fs.String("overlay-color", "#ff0000", "-overlay line colour as #rrggbb"),
+		palette:   fs.Bool("overlay-palette", false, "-overlay with a different colour per object instead of -overlay-color"),
+		thickness: fs.Float64("overlay-width", d.Thickness, "-overlay line width in pixels"),
+		antialias: fs.Bool("overlay-aa", d.Antialias, "-overlay with antialiased edges"),
+		opacity:   fs.Float64("overlay-opacity", d.Opacity, "-overlay line opacity, 0 to 1"),
+		fill:      fs.Float64("overlay-fill", d.Fill, "-overlay fill opacity of the objects, 0 to 1; 0 draws outlines only"),
+		ids:       fs.Bool("overlay-ids", false, "-overlay with the object IDs written next to the objects"),
+	}
+}
+
+// apply replaces outImg with the contours of binImg drawn over img or
+// binImg when -overlay is set; otherwise it returns outImg unchanged.
+func (f overlayFlags) apply(ctx context.Context, img, binImg, outImg image.Image) (image.Image, error) {
+	var base image.Image
+	switch *f.base {
+	case "":
+		return outImg, nil
+	case "original":
+		base = img
+	case "mask":
+		base = binImg
+	default:
+		return nil, fmt.Errorf("unknown -overlay %q (want original or mask)", *f.base)
+	}
+	if binImg == nil {
+		return nil, fmt.Errorf("-overlay needs a binary mask, which this segmentation mode does not produce")
+	}
+	style, err := f.style()
+	if err != nil {
+		return nil, err
+	}
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return nil, err
+	}
+	return imageutil.DrawOverlay(ctx, base, contours, style)
+}
+
+// style returns the overlay style set by the flags.
+func (f overlayFlags) style() (imageutil.OverlayStyle, error) {
+	col, err := parseHexColor(*f.color)
+	if err != nil {
+		return imageutil.OverlayStyle{}, err
+	}
+	style := imageutil.OverlayStyle{
+		Color:     col,
+		Thickness: *f.thickness,
+		Antialias: *f.antialias,
+		Opacity:   *f.opacity,
+		Fill:      *f.fill,
+		Labels:    *f.ids,
+	}
+	if *f.palette {
+		style.Palette = imageutil.OverlayPalette
+	}
+	return style, nil
+}
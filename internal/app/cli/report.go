@@ -0,0 +1,89 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"io"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// reportFlags - command-line flags for the contour data report.
+type reportFlags struct {
+	format *string
+	path   *string
+	schema *string
+}
+
+func addReportFlags(fs *flag.FlagSet) reportFlags {
+	return reportFlags{
+		format: fs.String("format", "", "also write the contour data as json|csv|ndjson (or give -out a .json/.csv/.ndjson name to write only the data)"),
+		path:   fs.String("data", "", "-format output file (default: -out with the format's extension)"),
+		schema: fs.String("schema", "", "write the JSON Schema of the json/ndjson report to this file"),
+	}
+}
+
+// source - the input the report describes.
+type source struct {
+	path   string
+	format string // decoder name
+	img    image.Image
+}
+
+// write saves the schema and, if -format is set, the report next to the
+// output image.
+func (f reportFlags) write(ctx context.Context, outPath string, src source, binImg image.Image, segOpts imageutil.SegmentOptions) error {
+	if *f.schema != "" {
+		if err := os.WriteFile(*f.schema, imageutil.ReportSchema, 0o644); err != nil {
+			return err
+		}
+	}
+	if *f.format == "" {
+		return nil
+	}
+	format := strings.ToLower(*f.format)
+	if !slices.Contains(imageutil.ReportFormats, format) {
+		return fmt.Errorf("unsupported -format %q (want %s)", *f.format, strings.Join(imageutil.ReportFormats, ", "))
+	}
+	path := *f.path
+	if path == "" {
+		path = strings.TrimSuffix(outPath, filepath.Ext(outPath)) + "." + format
+	}
+	return writeReport(ctx, path, format, src, binImg, segOpts)
+}
+
+// writeReport measures the contours of binImg and writes them in format.
+func writeReport(ctx context.Context, path, format string, src source, binImg image.Image, segOpts imageutil.SegmentOptions) error {
+	if binImg == nil {
+		return fmt.Errorf("contour reports need a binary mask, which this segmentation mode does not produce")
+	}
+	threshold := imageutil.ReportThreshold{Mode: imageutil.ResolveMode(src.img, segOpts.Mode)}
+	level, ok, err := imageutil.SegmentThreshold(ctx, src.img, segOpts)
+	if err != nil {
+		return err
+	}
+	if ok {
+		threshold.Value = &level
+	}
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return err
+	}
+	b := src.img.Bounds()
+	r, err := imageutil.NewReport(ctx, imageutil.ReportImage{
+		Path:   src.path,
+		Format: src.format,
+		Width:  b.Dx(),
+		Height: b.Dy(),
+	}, threshold, contours)
+	if err != nil {
+		return err
+	}
+	return writeFile(path, func(w io.Writer) error { return imageutil.WriteReport(w, r, format) })
+}
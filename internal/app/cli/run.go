@@ -41,17 +41,84 @@ func encoderFor(fmtName string) (encoderFn, error) {
 	}
 }
 
+// writeImage encodes img into a new file, picking the format from the file extension.
+func writeImage(path string, img image.Image) error {
+	enc, err := encoderFor(strings.TrimPrefix(filepath.Ext(path), "."))
+	if err != nil {
+		return err
+	}
+	f, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return enc(f, img)
+}
+
+// setFlag - a flag and whether it was given.
+type setFlag struct {
+	name string
+	set  bool
+}
+
+// checkExclusive returns an error if flag name, which replaces the output
+// image, is set together with one of others drawing on that image.
+func checkExclusive(name string, set bool, others ...setFlag) error {
+	if !set {
+		return nil
+	}
+	for _, o := range others {
+		if o.set {
+			return fmt.Errorf("-%s replaces the output image and cannot be combined with -%s", name, o.name)
+		}
+	}
+	return nil
+}
+
 // Run executes the command-line interface logic.
 func Run(ctx context.Context, args []string) error {
+	if len(args) > 0 {
+		switch args[0] {
+		case "document":
+			return runDocument(ctx, args[1:])
+		case "flatbed":
+			return runFlatbed(ctx, args[1:])
+		}
+	}
+
 	cliFlags := flag.NewFlagSet("cli", flag.ExitOnError)
 
 	// Define flags for the CLI mode. Note that -outfmt is now gone.
 	inPath := cliFlags.String("in", "", "input image (png, jpg, etc) (required)")
-	outPath := cliFlags.String("out", "out.png", "output file (format inferred from extension)")
+	outPath := cliFlags.String("out", "out.png", "output file (format inferred from extension; .svg, .dxf, .gcode/.nc and .hpgl/.plt write the contours as vectors, .json/.csv/.ndjson as data)")
 	logMode := cliFlags.String("log", "auto", "log output mode: auto|json|text")
+	segFlags := addSegmentFlags(cliFlags)
+	skelFlags := addSkeletonFlags(cliFlags)
+	trim := addTrimFlags(cliFlags)
+	metrics := addMetricsFlags(cliFlags)
+	match := addMatchFlags(cliFlags)
+	fourier := addFourierFlags(cliFlags)
+	vector := addVectorFlags(cliFlags)
+	corners := addCornerFlags(cliFlags)
+	svg := addSVGFlags(cliFlags)
+	dxf := addDXFFlags(cliFlags)
+	toolpath := addToolpathFlags(cliFlags)
+	report := addReportFlags(cliFlags)
+	geo := addGeoFlags(cliFlags)
+	overlay := addOverlayFlags(cliFlags)
+	cutout := addCutoutFlags(cliFlags)
+	crops := addCropFlags(cliFlags)
+	layers := addLayerFlags(cliFlags)
+	animate := addAnimateFlags(cliFlags)
+	splitTouching := cliFlags.Bool("split-touching", false, "separate touching objects with a distance-transform watershed")
+	splitDepth := cliFlags.Float64("split-depth", imageutil.DefaultSplitDepth, "-split-touching minimum peak height above the saddle, in pixels")
+	deskew := cliFlags.Bool("deskew", false, "estimate the skew of the page and straighten it before scanning; not with -geojson or -wkt")
+	deskewMax := cliFlags.Float64("deskew-max", imageutil.DefaultMaxSkew, "-deskew largest angle searched, in degrees")
 
 	cliFlags.Usage = func() {
-		fmt.Fprintf(os.Stderr, "Usage: %s cli [flags]\n\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "Usage: %s cli [flags]\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "       %s cli document [flags]   flatten a photographed page\n", filepath.Base(os.Args[0]))
+		fmt.Fprintf(os.Stderr, "       %s cli flatbed [flags]    split a scan of several photos\n\n", filepath.Base(os.Args[0]))
 		fmt.Fprintln(os.Stderr, "Flags for cli command:")
 		cliFlags.PrintDefaults()
 	}
@@ -88,11 +155,48 @@ func Run(ctx context.Context, args []string) error {
 	fmt.Printf("Log mode: %s\n", *logMode)
 
 	// Get the encoder function based on the determined format.
-	enc, err := encoderFor(outputFormat)
+	// Vector formats are written from the contours rather than encoded from outImg.
+	vectorOut := strings.ToLower(outputFormat)
+	switch vectorOut {
+	case "svg", "dxf", "gcode", "nc", "ngc", "hpgl", "plt", "json", "csv", "ndjson":
+	default:
+		vectorOut = ""
+	}
+	var enc encoderFn
+	if vectorOut == "" {
+		var err error
+		enc, err = encoderFor(outputFormat)
+		if err != nil {
+			// This will now catch invalid extensions like "result.txt".
+			return err
+		}
+	}
+
+	segOpts, err := segFlags.options()
 	if err != nil {
-		// This will now catch invalid extensions like "result.txt".
 		return err
 	}
+	// -match draws on the original, dropping the annotated contour render
+	if err := checkExclusive("match", *match.template != "",
+		setFlag{"overlay", *overlay.base != ""},
+		setFlag{"shape-labels", *metrics.labels},
+		setFlag{"corners", *corners.markers},
+	); err != nil {
+		return err
+	}
+	// -trim writes the cropped original instead of everything drawn so far
+	if err := checkExclusive("trim", *trim.enabled,
+		setFlag{"overlay", *overlay.base != ""},
+		setFlag{"shape-labels", *metrics.labels},
+		setFlag{"corners", *corners.markers},
+		setFlag{"match", *match.template != ""},
+	); err != nil {
+		return err
+	}
+	// deskewed pixels no longer line up with the input's geotransform
+	if *deskew && (*geo.geojson != "" || *geo.wkt != "") {
+		return fmt.Errorf("-deskew cannot be combined with -geojson or -wkt")
+	}
 
 	// --- END OF NEW LOGIC ---
 
@@ -104,22 +208,142 @@ func Run(ctx context.Context, args []string) error {
 	defer src.Close()
 
 	// Decode image
-	img, _, err := image.Decode(src)
+	img, inFormat, err := image.Decode(src)
 	if err != nil {
 		return err
 	}
 
-	// Process image (Otsu binarization and contour drawing)
-	binImg, err := imageutil.OtsuBinarize(ctx, img)
+	// Optional skew correction, estimated on an Otsu mask
+	if *deskew {
+		bin, err := imageutil.OtsuBinarize(ctx, img)
+		if err != nil {
+			return err
+		}
+		var angle float64
+		img, angle, err = imageutil.Deskew(ctx, img, bin, *deskewMax)
+		if err != nil {
+			return err
+		}
+		fmt.Printf("Skew: %.2f°\n", angle)
+	}
+
+	// Process image (segmentation and contour drawing)
+	var binImg, outImg image.Image
+	if segOpts.Mode == imageutil.ModeKMeans {
+		res, err := imageutil.KMeansSegment(ctx, img, segOpts.K, segOpts.Seed)
+		if err != nil {
+			return err
+		}
+		outImg, err = imageutil.DrawClusterContours(ctx, img.Bounds(), res.Clusters)
+		if err != nil {
+			return err
+		}
+	} else {
+		binImg, err = imageutil.Segment(ctx, img, segOpts)
+		if err != nil {
+			return err
+		}
+		if *splitTouching {
+			binImg, err = imageutil.SplitTouching(ctx, binImg, *splitDepth)
+			if err != nil {
+				return err
+			}
+		}
+		outImg, err = imageutil.DrawScannedContours(ctx, binImg)
+		if err != nil {
+			return err
+		}
+	}
+
+	// Optional centreline outputs
+	if err := skelFlags.write(ctx, binImg); err != nil {
+		return err
+	}
+
+	// Optional shape descriptors
+	if err := fourier.write(ctx, binImg); err != nil {
+		return err
+	}
+
+	// Optional vector tracing
+	if err := vector.write(ctx, binImg); err != nil {
+		return err
+	}
+
+	// Optional contour data report
+	input := source{path: *inPath, format: inFormat, img: img}
+	if err := report.write(ctx, outFilename, input, binImg, segOpts); err != nil {
+		return err
+	}
+
+	// Optional GIS polygons
+	if err := geo.write(ctx, *inPath, binImg); err != nil {
+		return err
+	}
+
+	// Optional object mask and background removal
+	if err := cutout.write(ctx, img, binImg); err != nil {
+		return err
+	}
+
+	// Optional per-object crops
+	if err := crops.write(ctx, *inPath, img, binImg); err != nil {
+		return err
+	}
+
+	// Optional layered stage file
+	if err := layers.write(ctx, img, binImg, overlay); err != nil {
+		return err
+	}
+
+	// Optional scan-progression animation
+	if err := animate.write(ctx, binImg); err != nil {
+		return err
+	}
+
+	// Optional contours drawn over the original or the mask
+	outImg, err = overlay.apply(ctx, img, binImg, outImg)
 	if err != nil {
 		return err
 	}
 
-	outImg, err := imageutil.DrawScannedContours(ctx, binImg)
+	// Optional measurements and shape labels
+	outImg, err = metrics.apply(ctx, binImg, outImg)
 	if err != nil {
 		return err
 	}
 
+	// Optional corner markers
+	outImg, err = corners.apply(ctx, binImg, outImg)
+	if err != nil {
+		return err
+	}
+
+	// Optional template matching
+	outImg, err = match.apply(ctx, img, binImg, outImg, segOpts)
+	if err != nil {
+		return err
+	}
+
+	// Optional crop of the original to the objects
+	outImg, err = trim.apply(ctx, img, binImg, outImg)
+	if err != nil {
+		return err
+	}
+
+	switch vectorOut {
+	case "svg":
+		return svg.write(ctx, outFilename, img, binImg, vector)
+	case "dxf":
+		return dxf.write(ctx, outFilename, binImg)
+	case "gcode", "nc", "ngc":
+		return toolpath.write(ctx, outFilename, false, binImg)
+	case "hpgl", "plt":
+		return toolpath.write(ctx, outFilename, true, binImg)
+	case "json", "csv", "ndjson":
+		return writeReport(ctx, outFilename, vectorOut, input, binImg, segOpts)
+	}
+
 	// Create output file
 	dst, err := os.Create(outFilename)
 	if err != nil {
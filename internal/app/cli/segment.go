@@ -0,0 +1,112 @@
+package cli
+
+import (
+	"flag"
+	"fmt"
+	"image/color"
+	"strconv"
+	"strings"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// segmentFlags - command-line flags selecting the segmentation mode.
+type segmentFlags struct {
+	mode    *string
+	hsv     *string
+	ref     *string
+	dist    *float64
+	channel *string
+	cutoff  *uint
+	k       *int
+	seed    *int64
+	sigma   *float64
+	low     *float64
+	high    *float64
+}
+
+func addSegmentFlags(fs *flag.FlagSet) segmentFlags {
+	return segmentFlags{
+		mode:    fs.String("mode", "auto", "segmentation mode: auto|otsu|hsv|lab|channel|alpha|kmeans|sobel|scharr|canny"),
+		hsv:     fs.String("hsv", "0,360,0,1,0,1", "hsv mode range: hmin,hmax,smin,smax,vmin,vmax (hue wraps if hmin > hmax)"),
+		ref:     fs.String("ref", "", "lab mode reference colour as #rrggbb"),
+		dist:    fs.Float64("dist", 20, "lab mode maximum colour distance (CIE76 delta E)"),
+		channel: fs.String("channel", "r", "channel mode channel: r|g|b|a"),
+		cutoff:  fs.Uint("alpha-cutoff", imageutil.DefaultAlphaCutoff, "alpha mode cutoff (0-254): pixels with higher alpha are object"),
+		k:       fs.Int("k", 4, "kmeans mode number of colour clusters"),
+		seed:    fs.Int64("seed", 1, "kmeans mode random seed"),
+		sigma:   fs.Float64("canny-sigma", imageutil.DefaultCannyOptions.Sigma, "canny mode Gaussian smoothing sigma"),
+		low:     fs.Float64("canny-low", 0, "canny mode low hysteresis threshold (0-255); needs -canny-high"),
+		high:    fs.Float64("canny-high", 0, "canny mode high hysteresis threshold (0-255), 0 - both thresholds automatic"),
+	}
+}
+
+// options converts the parsed flags into segmentation options.
+func (f segmentFlags) options() (imageutil.SegmentOptions, error) {
+	opts := imageutil.SegmentOptions{
+		Mode:        imageutil.SegmentMode(strings.ToLower(*f.mode)),
+		MaxDistance: *f.dist,
+		K:           *f.k,
+		Seed:        *f.seed,
+		Canny: imageutil.CannyOptions{
+			Sigma: *f.sigma,
+			Low:   *f.low,
+			High:  *f.high,
+		},
+	}
+	if *f.cutoff > 254 {
+		return opts, fmt.Errorf("invalid -alpha-cutoff %d: want 0-254", *f.cutoff)
+	}
+	opts.AlphaCutoff = uint8(*f.cutoff)
+	if *f.low != 0 && *f.high == 0 {
+		return opts, fmt.Errorf("-canny-low needs -canny-high: without it both thresholds are automatic")
+	}
+
+	var err error
+	switch opts.Mode {
+	case imageutil.ModeHSV:
+		opts.HSV, err = parseHSVRange(*f.hsv)
+	case imageutil.ModeLab:
+		if *f.ref == "" {
+			return opts, fmt.Errorf("flag -ref is required for -mode %s", opts.Mode)
+		}
+		opts.Reference, err = parseHexColor(*f.ref)
+	case imageutil.ModeChannel:
+		opts.Channel, err = imageutil.ParseChannel(*f.channel)
+	}
+	return opts, err
+}
+
+// parseHSVRange parses "hmin,hmax,smin,smax,vmin,vmax".
+func parseHSVRange(s string) (imageutil.HSVRange, error) {
+	parts := strings.Split(s, ",")
+	if len(parts) != 6 {
+		return imageutil.HSVRange{}, fmt.Errorf("invalid hsv range %q: want 6 comma-separated values", s)
+	}
+	var v [6]float64
+	for i, p := range parts {
+		f, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return imageutil.HSVRange{}, fmt.Errorf("invalid hsv range %q: %w", s, err)
+		}
+		v[i] = f
+	}
+	return imageutil.HSVRange{
+		HMin: v[0], HMax: v[1],
+		SMin: v[2], SMax: v[3],
+		VMin: v[4], VMax: v[5],
+	}, nil
+}
+
+// parseHexColor parses "#rrggbb" or "rrggbb".
+func parseHexColor(s string) (color.Color, error) {
+	hex := strings.TrimPrefix(s, "#")
+	if len(hex) != 6 {
+		return nil, fmt.Errorf("invalid colour %q: want #rrggbb", s)
+	}
+	v, err := strconv.ParseUint(hex, 16, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid colour %q: %w", s, err)
+	}
+	return color.NRGBA{R: uint8(v >> 16), G: uint8(v >> 8), B: uint8(v), A: 255}, nil
+}
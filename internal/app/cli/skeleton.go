@@ -0,0 +1,69 @@
+package cli
+
+import (
+	"context"
+	"encoding/json"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// skeletonFlags - command-line flags for centreline extraction.
+type skeletonFlags struct {
+	method    *string
+	image     *string
+	polylines *string
+}
+
+func addSkeletonFlags(fs *flag.FlagSet) skeletonFlags {
+	return skeletonFlags{
+		method:    fs.String("thinning", "zhang-suen", "skeleton thinning method: zhang-suen|guo-hall"),
+		image:     fs.String("skeleton", "", "write the one-pixel skeleton image to this file"),
+		polylines: fs.String("polylines", "", "write the skeleton graph (nodes and polylines) as JSON to this file"),
+	}
+}
+
+// write produces the requested skeleton outputs from the binary mask.
+func (f skeletonFlags) write(ctx context.Context, binImg image.Image) error {
+	if *f.image == "" && *f.polylines == "" {
+		return nil
+	}
+	if binImg == nil {
+		return fmt.Errorf("skeleton outputs need a binary mask, which this segmentation mode does not produce")
+	}
+	method, err := imageutil.ParseThinningMethod(*f.method)
+	if err != nil {
+		return err
+	}
+
+	skel, err := imageutil.Skeletonize(ctx, binImg, method)
+	if err != nil {
+		return err
+	}
+	if *f.image != "" {
+		if err := writeImage(*f.image, skel); err != nil {
+			return err
+		}
+	}
+
+	if *f.polylines != "" {
+		graph, err := imageutil.SkeletonToGraph(ctx, skel)
+		if err != nil {
+			return err
+		}
+		out, err := os.Create(*f.polylines)
+		if err != nil {
+			return err
+		}
+		defer out.Close()
+		enc := json.NewEncoder(out)
+		enc.SetIndent("", "  ")
+		if err := enc.Encode(graph); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,53 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// svgFlags - command-line flags for -out *.svg.
+type svgFlags struct {
+	background *bool
+	smooth     *bool
+}
+
+func addSVGFlags(fs *flag.FlagSet) svgFlags {
+	return svgFlags{
+		background: fs.Bool("svg-background", false, "-out *.svg: embed the input image under the contours"),
+		smooth:     fs.Bool("svg-smooth", false, "-out *.svg: trace the contours with Bezier curves (see -vector-tolerance, -vector-corner)"),
+	}
+}
+
+// write saves the contours of the binary mask as SVG, with img as the
+// optional background layer.
+func (f svgFlags) write(ctx context.Context, path string, img, binImg image.Image, vector vectorFlags) error {
+	if binImg == nil {
+		return fmt.Errorf("SVG output needs a binary mask, which this segmentation mode does not produce")
+	}
+	if *f.smooth && (*vector.tolerance <= 0 || *vector.corner <= 0 || *vector.corner > 180) {
+		return fmt.Errorf("invalid -vector-tolerance %g or -vector-corner %g", *vector.tolerance, *vector.corner)
+	}
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return err
+	}
+	opts := imageutil.SVGOptions{
+		Smooth: *f.smooth,
+		Vector: imageutil.VectorOptions{Tolerance: *vector.tolerance, CornerAngle: *vector.corner},
+	}
+	if *f.background {
+		opts.Background = img
+	}
+
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	return imageutil.WriteContoursSVG(ctx, out, binImg.Bounds(), contours, opts)
+}
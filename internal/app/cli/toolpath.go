@@ -0,0 +1,90 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// toolpathFlags - command-line flags for -out *.gcode and *.hpgl.
+type toolpathFlags struct {
+	scale    *float64
+	simplify *float64
+	feed     *float64
+	travel   *float64
+	zUp      *float64
+	zDown    *float64
+	penUp    *string
+	penDown  *string
+	preview  *string
+}
+
+func addToolpathFlags(fs *flag.FlagSet) toolpathFlags {
+	t, m := imageutil.DefaultToolpathOptions, imageutil.DefaultMachineOptions
+	return toolpathFlags{
+		scale:    fs.Float64("tool-scale", t.Scale, "-out *.gcode/*.hpgl: millimetres per pixel"),
+		simplify: fs.Float64("tool-simplify", t.Simplify, "-out *.gcode/*.hpgl: merge points within this many pixels of a straight line"),
+		feed:     fs.Float64("tool-feed", m.Feed, "-out *.gcode/*.hpgl: cutting feed in mm/min"),
+		travel:   fs.Float64("tool-travel", m.Travel, "-out *.gcode: travel feed in mm/min"),
+		zUp:      fs.Float64("tool-z-up", m.ZUp, "-out *.gcode: safe Z height for travel moves, mm"),
+		zDown:    fs.Float64("tool-z-down", m.ZDown, "-out *.gcode: Z height while cutting or drawing, mm"),
+		penUp:    fs.String("tool-pen-up", "", "-out *.gcode: command lifting the pen (e.g. M5), instead of Z moves"),
+		penDown:  fs.String("tool-pen-down", "", "-out *.gcode: command lowering the pen (e.g. \"M3 S90\"), instead of Z moves"),
+		preview:  fs.String("tool-preview", "", "-out *.gcode/*.hpgl: also draw the toolpath, travel moves dashed, to this image file"),
+	}
+}
+
+// write saves the contours of the binary mask as a G-code or HPGL toolpath.
+func (f toolpathFlags) write(ctx context.Context, path string, hpgl bool, binImg image.Image) error {
+	if binImg == nil {
+		return fmt.Errorf("toolpath output needs a binary mask, which this segmentation mode does not produce")
+	}
+	if *f.scale <= 0 || *f.feed <= 0 || *f.travel <= 0 {
+		return fmt.Errorf("invalid -tool-scale %g, -tool-feed %g or -tool-travel %g", *f.scale, *f.feed, *f.travel)
+	}
+	if (*f.penUp == "") != (*f.penDown == "") {
+		return fmt.Errorf("-tool-pen-up and -tool-pen-down must be given together")
+	}
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return err
+	}
+	topts := imageutil.ToolpathOptions{Scale: *f.scale, Simplify: *f.simplify}
+	tp, err := imageutil.BuildToolpath(ctx, binImg.Bounds(), contours, topts)
+	if err != nil {
+		return err
+	}
+	fmt.Printf("Toolpath: %d loops, %.1f mm travel\n", len(tp.Loops), tp.TravelLength())
+
+	if *f.preview != "" {
+		img, err := imageutil.DrawToolpath(ctx, binImg.Bounds(), tp, topts)
+		if err != nil {
+			return err
+		}
+		if err := writeImage(*f.preview, img); err != nil {
+			return err
+		}
+	}
+
+	mopts := imageutil.MachineOptions{
+		Feed:    *f.feed,
+		Travel:  *f.travel,
+		ZUp:     *f.zUp,
+		ZDown:   *f.zDown,
+		PenUp:   *f.penUp,
+		PenDown: *f.penDown,
+	}
+	out, err := os.Create(path)
+	if err != nil {
+		return err
+	}
+	defer out.Close()
+	if hpgl {
+		return imageutil.WriteHPGL(out, tp, mopts)
+	}
+	return imageutil.WriteGCode(out, tp, mopts)
+}
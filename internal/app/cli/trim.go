@@ -0,0 +1,50 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// trimFlags - command-line flags for cropping the original to the objects.
+type trimFlags struct {
+	enabled *bool
+	largest *bool
+	padding *int
+	aspect  *string
+}
+
+func addTrimFlags(fs *flag.FlagSet) trimFlags {
+	return trimFlags{
+		enabled: fs.Bool("trim", false, "write the original cropped to the detected objects instead of the contour render (not with -overlay, -shape-labels, -corners or -match)"),
+		largest: fs.Bool("trim-largest", false, "-trim to the largest object only instead of all objects"),
+		padding: fs.Int("trim-pad", 0, "-trim padding around the objects, in pixels, 0 or more"),
+		aspect:  fs.String("trim-aspect", "", "-trim aspect ratio as w:h or a number, e.g. 16:9; empty - keep the objects' own"),
+	}
+}
+
+// apply crops img to the objects of binImg when -trim is set; otherwise it
+// returns outImg unchanged.
+func (f trimFlags) apply(ctx context.Context, img, binImg, outImg image.Image) (image.Image, error) {
+	if !*f.enabled {
+		return outImg, nil
+	}
+	if binImg == nil {
+		return nil, fmt.Errorf("-trim needs a binary mask, which this segmentation mode does not produce")
+	}
+	if *f.padding < 0 {
+		return nil, fmt.Errorf("invalid -trim-pad %d (want 0 or more)", *f.padding)
+	}
+	aspect, err := imageutil.ParseAspect(*f.aspect)
+	if err != nil {
+		return nil, err
+	}
+	return imageutil.Trim(ctx, img, binImg, imageutil.TrimOptions{
+		Largest: *f.largest,
+		Padding: *f.padding,
+		Aspect:  aspect,
+	})
+}
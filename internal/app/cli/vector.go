@@ -0,0 +1,57 @@
+package cli
+
+import (
+	"context"
+	"flag"
+	"fmt"
+	"image"
+	"os"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// vectorFlags - command-line flags for Bezier vectorization.
+type vectorFlags struct {
+	path      *string
+	tolerance *float64
+	corner    *float64
+}
+
+func addVectorFlags(fs *flag.FlagSet) vectorFlags {
+	return vectorFlags{
+		path:      fs.String("vector", "", "trace the contours with lines and Bezier curves and write them as SVG to this file"),
+		tolerance: fs.Float64("vector-tolerance", imageutil.DefaultVectorOptions.Tolerance, "-vector largest distance of the curves from the contour, in pixels"),
+		corner:    fs.Float64("vector-corner", imageutil.DefaultVectorOptions.CornerAngle, "-vector angles sharper than this (degrees) are kept as corners"),
+	}
+}
+
+// write traces the binary mask and saves the SVG drawing.
+func (f vectorFlags) write(ctx context.Context, binImg image.Image) error {
+	if *f.path == "" {
+		return nil
+	}
+	if binImg == nil {
+		return fmt.Errorf("vectorization needs a binary mask, which this segmentation mode does not produce")
+	}
+	if *f.tolerance <= 0 || *f.corner <= 0 || *f.corner > 180 {
+		return fmt.Errorf("invalid -vector-tolerance %g or -vector-corner %g", *f.tolerance, *f.corner)
+	}
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return err
+	}
+	paths, err := imageutil.VectorizeContours(ctx, contours, imageutil.VectorOptions{
+		Tolerance:   *f.tolerance,
+		CornerAngle: *f.corner,
+	})
+	if err != nil {
+		return err
+	}
+
+	file, err := os.Create(*f.path)
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+	return imageutil.WriteVectorSVG(file, binImg.Bounds(), paths)
+}
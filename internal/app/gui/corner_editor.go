@@ -0,0 +1,131 @@
+package gui
+
+import (
+	"image"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// ---- draggable document corners ----
+
+const handleRadius = 7
+
+// cornerEditor shows an image with a quadrilateral whose corners can be
+// dragged with the mouse.
+type cornerEditor struct {
+	widget.BaseWidget
+	img  *canvas.Image
+	Quad imageutil.Quad // in source image coordinates
+	drag int            // corner being dragged, -1 if none
+}
+
+func newCornerEditor(src image.Image, q imageutil.Quad) *cornerEditor {
+	img := canvas.NewImageFromImage(src)
+	img.FillMode = canvas.ImageFillContain
+	img.SetMinSize(fyne.NewSize(400, 400))
+	e := &cornerEditor{img: img, Quad: q, drag: -1}
+	e.ExtendBaseWidget(e)
+	return e
+}
+
+// toWidget maps image coordinates to a position inside the widget.
+func (e *cornerEditor) toWidget(p imageutil.PointF) fyne.Position {
+	b := e.img.Image.Bounds()
+	scale, off, _ := containPlacement(e.Size(), b)
+	return fyne.NewPos(
+		off.X+float32(p.X-float64(b.Min.X))*scale,
+		off.Y+float32(p.Y-float64(b.Min.Y))*scale,
+	)
+}
+
+// toImage maps a widget position to image coordinates, clamped to the image.
+func (e *cornerEditor) toImage(pos fyne.Position) imageutil.PointF {
+	b := e.img.Image.Bounds()
+	scale, off, ok := containPlacement(e.Size(), b)
+	if !ok {
+		return imageutil.PointF{}
+	}
+	x := float64((pos.X-off.X)/scale) + float64(b.Min.X)
+	y := float64((pos.Y-off.Y)/scale) + float64(b.Min.Y)
+	return imageutil.PointF{
+		X: min(max(x, float64(b.Min.X)), float64(b.Max.X)),
+		Y: min(max(y, float64(b.Min.Y)), float64(b.Max.Y)),
+	}
+}
+
+func (e *cornerEditor) Dragged(ev *fyne.DragEvent) {
+	if e.drag < 0 {
+		// pick the handle closest to where the drag started
+		start := ev.Position.Subtract(ev.Dragged)
+		best := float32(3 * handleRadius * 3 * handleRadius)
+		for i, c := range e.Quad {
+			d := e.toWidget(c).Subtract(start)
+			if dd := d.X*d.X + d.Y*d.Y; dd < best {
+				e.drag, best = i, dd
+			}
+		}
+		if e.drag < 0 {
+			return
+		}
+	}
+	e.Quad[e.drag] = e.toImage(ev.Position)
+	e.Refresh()
+}
+
+func (e *cornerEditor) DragEnd() {
+	e.drag = -1
+}
+
+func (e *cornerEditor) CreateRenderer() fyne.WidgetRenderer {
+	r := &cornerRenderer{e: e}
+	r.objects = append(r.objects, e.img)
+	edge := color.NRGBA{R: 255, G: 40, B: 40, A: 255}
+	for i := 0; i < 4; i++ {
+		r.lines[i] = canvas.NewLine(edge)
+		r.lines[i].StrokeWidth = 2
+		r.objects = append(r.objects, r.lines[i])
+	}
+	for i := 0; i < 4; i++ {
+		r.handles[i] = canvas.NewCircle(color.NRGBA{R: 255, G: 255, B: 255, A: 200})
+		r.handles[i].StrokeColor = edge
+		r.handles[i].StrokeWidth = 2
+		r.objects = append(r.objects, r.handles[i])
+	}
+	return r
+}
+
+type cornerRenderer struct {
+	e       *cornerEditor
+	lines   [4]*canvas.Line
+	handles [4]*canvas.Circle
+	objects []fyne.CanvasObject
+}
+
+func (r *cornerRenderer) Layout(size fyne.Size) {
+	r.e.img.Resize(size)
+	r.e.img.Move(fyne.NewPos(0, 0))
+	for i := 0; i < 4; i++ {
+		a := r.e.toWidget(r.e.Quad[i])
+		b := r.e.toWidget(r.e.Quad[(i+1)%4])
+		r.lines[i].Position1, r.lines[i].Position2 = a, b
+		r.handles[i].Position1 = a.SubtractXY(handleRadius, handleRadius)
+		r.handles[i].Position2 = a.AddXY(handleRadius, handleRadius)
+	}
+}
+
+func (r *cornerRenderer) MinSize() fyne.Size { return r.e.img.MinSize() }
+
+func (r *cornerRenderer) Refresh() {
+	r.Layout(r.e.Size())
+	for _, o := range r.objects {
+		o.Refresh()
+	}
+}
+
+func (r *cornerRenderer) Objects() []fyne.CanvasObject { return r.objects }
+func (r *cornerRenderer) Destroy()                     {}
@@ -0,0 +1,100 @@
+package gui
+
+import (
+	"context"
+	"image"
+	"image/png"
+	"os"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// view showing the original with the background removed
+const viewCutout = "Cut-out"
+
+// ---- mask and cut-out settings ----
+
+// cutoutPanel holds the edge feathering and the last mask and cut-out.
+type cutoutPanel struct {
+	feather float64
+	mask    image.Image // white objects on black, nil until computed
+	image   image.Image // original with transparent background
+
+	changed func() // called after a setting dropped the cut-out
+	content fyne.CanvasObject
+}
+
+func newCutoutPanel(parent fyne.Window) *cutoutPanel {
+	p := &cutoutPanel{}
+
+	// savePNG saves *img, which only exists once the view was shown
+	savePNG := func(img *image.Image) func() {
+		return func() {
+			if *img == nil {
+				dialog.ShowInformation("No cut-out", "Show the Cut-out view first", parent)
+				return
+			}
+			dialog.ShowFileSave(func(uc fyne.URIWriteCloser, err error) {
+				if err != nil || uc == nil {
+					return
+				}
+				defer uc.Close()
+				f, err := os.Create(withExt(uc.URI().Path(), ".png"))
+				if err != nil {
+					dialog.ShowError(err, parent)
+					return
+				}
+				defer f.Close()
+				if err := png.Encode(f, *img); err != nil {
+					dialog.ShowError(err, parent)
+				}
+			}, parent)
+		}
+	}
+
+	p.content = container.NewVBox(
+		widget.NewLabel("Background removal"),
+		labeledSliderNotify("Feather", 0, 10, 0.5, &p.feather, p.invalidate),
+		container.NewGridWithColumns(2,
+			widget.NewButton("Save mask", savePNG(&p.mask)),
+			widget.NewButton("Save cut-out", savePNG(&p.image)),
+		),
+	)
+	return p
+}
+
+// compute fills the contours of binImg and cuts the objects out of src.
+func (p *cutoutPanel) compute(ctx context.Context, src, binImg image.Image) error {
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return err
+	}
+	alpha, err := imageutil.ContourMask(ctx, binImg.Bounds(), contours, p.feather)
+	if err != nil {
+		return err
+	}
+	img, err := imageutil.Cutout(ctx, src, alpha)
+	if err != nil {
+		return err
+	}
+	p.mask, p.image = imageutil.MaskImage(alpha), img
+	return nil
+}
+
+// reset drops the mask and cut-out of the previous run.
+func (p *cutoutPanel) reset() {
+	p.mask, p.image = nil, nil
+}
+
+// invalidate drops the mask and cut-out after a settings change.
+func (p *cutoutPanel) invalidate() {
+	p.reset()
+	if p.changed != nil {
+		p.changed()
+	}
+}
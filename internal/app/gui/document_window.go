@@ -0,0 +1,101 @@
+package gui
+
+import (
+	"context"
+	"image"
+	"os"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/layout"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// ---- document flattening window ----
+func ShowDocumentWindow(src image.Image) {
+	app := fyne.CurrentApp()
+	w := app.NewWindow("Document")
+	w.Resize(fyne.NewSize(1000, 600))
+
+	q, err := imageutil.DetectDocument(context.TODO(), src)
+	if err != nil {
+		// start from a slightly inset frame the user can adjust
+		b := src.Bounds()
+		dx, dy := float64(b.Dx())/10, float64(b.Dy())/10
+		q = imageutil.Quad{
+			{X: float64(b.Min.X) + dx, Y: float64(b.Min.Y) + dy},
+			{X: float64(b.Max.X) - dx, Y: float64(b.Min.Y) + dy},
+			{X: float64(b.Max.X) - dx, Y: float64(b.Max.Y) - dy},
+			{X: float64(b.Min.X) + dx, Y: float64(b.Max.Y) - dy},
+		}
+	}
+	editor := newCornerEditor(src, q)
+
+	pageIV := canvas.NewImageFromImage(nil)
+	pageIV.FillMode = canvas.ImageFillContain
+	pageIV.SetMinSize(fyne.NewSize(400, 400))
+	var page image.Image
+
+	interp := imageutil.Bicubic
+	interpSel := widget.NewSelect(
+		[]string{imageutil.Bilinear.String(), imageutil.Bicubic.String()},
+		func(s string) { interp, _ = imageutil.ParseInterpolation(s) },
+	)
+	interpSel.SetSelected(interp.String())
+
+	warpBtn := widget.NewButton("Warp", func() {
+		var err error
+		page, err = imageutil.FlattenDocument(context.TODO(), src, editor.Quad, interp)
+		if err != nil {
+			dialog.ShowError(err, w)
+			return
+		}
+		pageIV.Image = page
+		pageIV.Refresh()
+	})
+
+	saveBtn := widget.NewButton("Save", func() {
+		if page == nil {
+			dialog.ShowInformation("Nothing to save", "Warp the document first", w)
+			return
+		}
+		dialog.ShowFileSave(func(uc fyne.URIWriteCloser, err error) {
+			if err != nil || uc == nil {
+				return
+			}
+			defer uc.Close()
+			fName := withExt(uc.URI().Path(), ".png")
+			enc, ok := encoders[extOf(fName)]
+			if !ok {
+				dialog.ShowInformation("Unsupported format", "Pick one of: "+encoderList(), w)
+				return
+			}
+			f, err := os.Create(fName)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			defer f.Close()
+			if err := enc(f, page); err != nil {
+				dialog.ShowError(err, w)
+			}
+		}, w)
+	})
+
+	grid := container.NewGridWithColumns(2,
+		container.NewBorder(widget.NewLabel("Drag the corners"), nil, nil, nil, editor),
+		container.NewBorder(widget.NewLabel("Flattened page"), nil, nil, nil, pageIV),
+	)
+	bottom := container.NewHBox(
+		widget.NewLabel("Resampling"), interpSel,
+		layout.NewSpacer(),
+		warpBtn, saveBtn,
+		widget.NewButton("Close", w.Close),
+	)
+	w.SetContent(container.NewBorder(nil, bottom, nil, nil, grid))
+	w.Show()
+}
@@ -1,13 +1,19 @@
 package gui
 
 import (
+	"context"
+	"fmt"
 	"image"
 	"image/gif"
 	"image/jpeg"
 	"image/png"
 	"os"
 	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
 
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
 	"golang.org/x/image/bmp"
 	"golang.org/x/image/tiff"
 )
@@ -37,3 +43,59 @@ func withExt(name, ext string) string {
 	}
 	return name
 }
+
+// extOf returns the lower-case extension of name, including the dot.
+func extOf(name string) string {
+	return strings.ToLower(filepath.Ext(name))
+}
+
+// encoderList returns the supported save extensions, sorted.
+func encoderList() string {
+	var exts []string
+	for ext := range encoders {
+		exts = append(exts, ext)
+	}
+	sort.Strings(exts)
+	return strings.Join(exts, ", ")
+}
+
+// writeSVG saves the contours of the binary mask as SVG paths, with bg
+// embedded underneath unless it is nil.
+func writeSVG(w *os.File, bin, bg image.Image) error {
+	ctx := context.TODO()
+	contours, err := imageutil.FindContours(ctx, bin)
+	if err != nil {
+		return err
+	}
+	return imageutil.WriteContoursSVG(ctx, w, bin.Bounds(), contours, imageutil.SVGOptions{Background: bg})
+}
+
+// writeDXF saves the contours of the binary mask as DXF polylines in
+// millimetres, scale being the mm per pixel typed by the user.
+func writeDXF(w *os.File, bin image.Image, scale string) error {
+	opts := imageutil.DefaultDXFOptions
+	v, err := strconv.ParseFloat(strings.TrimSpace(scale), 64)
+	if err != nil || v <= 0 {
+		return fmt.Errorf("invalid scale %q: want a positive number of mm per pixel", scale)
+	}
+	opts.Scale = v
+	ctx := context.TODO()
+	contours, err := imageutil.FindContours(ctx, bin)
+	if err != nil {
+		return err
+	}
+	return imageutil.WriteDXF(ctx, w, bin.Bounds(), contours, opts)
+}
+
+// writeAnimation saves the row-by-row scan of the binary mask as an
+// animated GIF, or an animated PNG if apng is set.
+func writeAnimation(w *os.File, bin image.Image, apng bool, opts imageutil.AnimationOptions) error {
+	frames, err := imageutil.ScanFrames(context.TODO(), bin, opts)
+	if err != nil {
+		return err
+	}
+	if apng {
+		return imageutil.WriteAPNG(w, frames, opts.Delay)
+	}
+	return imageutil.WriteGIFAnimation(w, frames, opts.Delay)
+}
@@ -0,0 +1,80 @@
+package gui
+
+import (
+	"image"
+	"math"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/widget"
+)
+
+// ---- tappable image view ----
+
+// imageView wraps a canvas.Image shown with ImageFillContain and reports taps
+// in source-image pixel coordinates.
+type imageView struct {
+	widget.BaseWidget
+	Image    *canvas.Image
+	OnTapped func(p image.Point) // called only for taps inside the image
+}
+
+func newImageView(img *canvas.Image) *imageView {
+	v := &imageView{Image: img}
+	v.ExtendBaseWidget(v)
+	return v
+}
+
+func (v *imageView) CreateRenderer() fyne.WidgetRenderer {
+	return widget.NewSimpleRenderer(v.Image)
+}
+
+func (v *imageView) MinSize() fyne.Size {
+	return v.Image.MinSize()
+}
+
+func (v *imageView) Tapped(ev *fyne.PointEvent) {
+	if v.OnTapped == nil {
+		return
+	}
+	if p, ok := v.toImage(ev.Position); ok {
+		v.OnTapped(p)
+	}
+}
+
+// placement returns the scale and offset used to fit the image into the view.
+func (v *imageView) placement() (scale float32, off fyne.Position, ok bool) {
+	if v.Image.Image == nil {
+		return 0, fyne.Position{}, false
+	}
+	return containPlacement(v.Size(), v.Image.Image.Bounds())
+}
+
+// containPlacement returns the scale and offset that fit an image with
+// bounds b into size while keeping its aspect ratio (ImageFillContain).
+func containPlacement(size fyne.Size, b image.Rectangle) (scale float32, off fyne.Position, ok bool) {
+	if b.Empty() {
+		return 0, fyne.Position{}, false
+	}
+	iw, ih := float32(b.Dx()), float32(b.Dy())
+	scale = size.Width / iw
+	if s := size.Height / ih; s < scale {
+		scale = s
+	}
+	off = fyne.NewPos((size.Width-iw*scale)/2, (size.Height-ih*scale)/2)
+	return scale, off, true
+}
+
+// toImage maps a widget position to the source pixel under it.
+func (v *imageView) toImage(pos fyne.Position) (image.Point, bool) {
+	scale, off, ok := v.placement()
+	if !ok {
+		return image.Point{}, false
+	}
+	b := v.Image.Image.Bounds()
+	p := image.Pt(
+		b.Min.X+int(math.Floor(float64((pos.X-off.X)/scale))),
+		b.Min.Y+int(math.Floor(float64((pos.Y-off.Y)/scale))),
+	)
+	return p, p.In(b)
+}
@@ -0,0 +1,144 @@
+package gui
+
+import (
+	"context"
+	"image"
+	"image/color"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// view showing the contours drawn over the original or the mask
+const viewOverlay = "Overlay"
+
+// overlay base choices
+const (
+	overlayOriginal = "Original"
+	overlayMask     = "Mask"
+)
+
+// ---- overlay settings ----
+
+// overlayPanel holds the overlay style and the last rendered overlay.
+type overlayPanel struct {
+	base      string
+	color     color.Color
+	palette   bool
+	thickness float64
+	antialias bool
+	opacity   float64
+	fill      float64
+	labels    bool
+	image     image.Image // overlay, nil until computed
+
+	changed func() // called after a setting dropped the overlay
+	content fyne.CanvasObject
+}
+
+func newOverlayPanel(parent fyne.Window) *overlayPanel {
+	d := imageutil.DefaultOverlayStyle
+	p := &overlayPanel{
+		base:      overlayOriginal,
+		color:     d.Color,
+		thickness: d.Thickness,
+		antialias: d.Antialias,
+		opacity:   d.Opacity,
+		fill:      d.Fill,
+	}
+
+	baseSel := widget.NewSelect([]string{overlayOriginal, overlayMask}, func(s string) {
+		p.base = s
+		p.invalidate()
+	})
+	baseSel.SetSelected(p.base)
+
+	swatch := canvas.NewRectangle(p.color)
+	swatch.SetMinSize(fyne.NewSize(24, 24))
+	colorLabel := widget.NewLabel(hexColor(p.color))
+	colorBtn := widget.NewButton("Line colour", func() {
+		picker := dialog.NewColorPicker("Line colour", "Colour of the contours", func(c color.Color) {
+			p.color = c
+			swatch.FillColor = c
+			swatch.Refresh()
+			colorLabel.SetText(hexColor(c))
+			p.invalidate()
+		}, parent)
+		picker.Advanced = true
+		picker.Show()
+	})
+
+	aa := widget.NewCheck("Antialias", nil)
+	aa.SetChecked(p.antialias)
+	aa.OnChanged = func(on bool) {
+		p.antialias = on
+		p.invalidate()
+	}
+
+	p.content = container.NewVBox(
+		widget.NewLabel("Overlay"),
+		baseSel,
+		container.NewHBox(swatch, colorLabel),
+		colorBtn,
+		widget.NewCheck("Colour per object", func(on bool) {
+			p.palette = on
+			p.invalidate()
+		}),
+		labeledSliderNotify("Line width", 0.5, 10, 0.5, &p.thickness, p.invalidate),
+		aa,
+		labeledSliderNotify("Line opacity", 0, 1, 0.05, &p.opacity, p.invalidate),
+		labeledSliderNotify("Fill opacity", 0, 1, 0.05, &p.fill, p.invalidate),
+		widget.NewCheck("Object IDs", func(on bool) {
+			p.labels = on
+			p.invalidate()
+		}),
+	)
+	return p
+}
+
+// compute draws the contours of binImg over src or binImg.
+func (p *overlayPanel) compute(ctx context.Context, src, binImg image.Image) error {
+	contours, err := imageutil.FindContours(ctx, binImg)
+	if err != nil {
+		return err
+	}
+	style := imageutil.OverlayStyle{
+		Color:     p.color,
+		Thickness: p.thickness,
+		Antialias: p.antialias,
+		Opacity:   p.opacity,
+		Fill:      p.fill,
+		Labels:    p.labels,
+	}
+	if p.palette {
+		style.Palette = imageutil.OverlayPalette
+	}
+	base := src
+	if p.base == overlayMask {
+		base = binImg
+	}
+	img, err := imageutil.DrawOverlay(ctx, base, contours, style)
+	if err != nil {
+		return err
+	}
+	p.image = img
+	return nil
+}
+
+// reset drops the overlay of the previous run.
+func (p *overlayPanel) reset() {
+	p.image = nil
+}
+
+// invalidate drops the overlay after a style change.
+func (p *overlayPanel) invalidate() {
+	p.reset()
+	if p.changed != nil {
+		p.changed()
+	}
+}
@@ -2,8 +2,11 @@ package gui
 
 import (
 	"context"
+	"fmt"
 	"image"
 	"os"
+	"sort"
+	"strconv"
 	"strings"
 
 	"fyne.io/fyne/v2"
@@ -23,10 +26,11 @@ import (
 func Run(_ context.Context, _ string) error {
 	a := app.New()
 	w := a.NewWindow("Border-scanner viewer")
-	w.Resize(fyne.NewSize(900, 600))
+	w.Resize(fyne.NewSize(1100, 600))
 
 	var (
 		inImg  image.Image // original
+		srcImg image.Image // original after optional deskew
 		binImg image.Image // binarized
 		outImg image.Image // processed
 	)
@@ -34,6 +38,14 @@ func Run(_ context.Context, _ string) error {
 	inIV := canvas.NewImageFromImage(nil)
 	inIV.FillMode = canvas.ImageFillContain
 	inIV.SetMinSize(fyne.NewSize(400, 400))
+	inView := newImageView(inIV)
+
+	segPanel := newSegmentPanel()
+	inView.OnTapped = func(p image.Point) {
+		if inImg != nil {
+			segPanel.Pick(inImg.At(p.X, p.Y))
+		}
+	}
 
 	outIV := canvas.NewImageFromImage(nil)
 	outIV.FillMode = canvas.ImageFillContain
@@ -43,6 +55,78 @@ func Run(_ context.Context, _ string) error {
 	btnRun := widget.NewButton("Run", nil)
 	btnSave := widget.NewButton("Save", nil)
 	btnStep := widget.NewButton("Detailed viewer", nil)
+	btnDoc := widget.NewButton("Document", nil)
+	status := widget.NewLabel("")
+
+	skelPanel := newSkeletonPanel(w)
+	trimPanel := newTrimPanel()
+	overlayPanel := newOverlayPanel(w)
+	cutoutPanel := newCutoutPanel(w)
+
+	// --- output views ---
+	allViews := []string{viewContours, viewMask, viewSkeleton, viewCropped, viewOverlay, viewCutout}
+	viewSel := widget.NewSelect(allViews, nil)
+	shown := func() image.Image {
+		switch viewSel.Selected {
+		case viewMask:
+			return binImg
+		case viewSkeleton:
+			return skelPanel.image
+		case viewCropped:
+			return trimPanel.image
+		case viewOverlay:
+			return overlayPanel.image
+		case viewCutout:
+			return cutoutPanel.image
+		}
+		return outImg
+	}
+	viewSel.OnChanged = func(s string) {
+		if s == viewSkeleton && binImg != nil && skelPanel.image == nil {
+			if err := skelPanel.compute(context.TODO(), binImg); err != nil {
+				dialog.ShowError(err, w)
+			}
+		}
+		if s == viewCropped && binImg != nil && trimPanel.image == nil {
+			if err := trimPanel.compute(context.TODO(), srcImg, binImg); err != nil {
+				dialog.ShowError(err, w)
+			}
+		}
+		if s == viewOverlay && binImg != nil && overlayPanel.image == nil {
+			if err := overlayPanel.compute(context.TODO(), srcImg, binImg); err != nil {
+				dialog.ShowError(err, w)
+			}
+		}
+		if s == viewCutout && binImg != nil && cutoutPanel.image == nil {
+			if err := cutoutPanel.compute(context.TODO(), srcImg, binImg); err != nil {
+				dialog.ShowError(err, w)
+			}
+		}
+		outIV.Image = shown()
+		outIV.Refresh()
+	}
+	viewSel.SetSelected(viewContours)
+
+	// settings changes drop the cached image; show it again, recomputed
+	refresh := func() { viewSel.OnChanged(viewSel.Selected) }
+	skelPanel.changed = refresh
+	trimPanel.changed = refresh
+	overlayPanel.changed = refresh
+	cutoutPanel.changed = refresh
+
+	// maskViews offers the views and exports built on the binary mask only
+	// when there is one; k-means mode produces clusters instead
+	maskViews := func(on bool) {
+		if on {
+			viewSel.Options = allViews
+			btnStep.Enable()
+		} else {
+			viewSel.Options = allViews[:1]
+			viewSel.Selected = viewContours
+			btnStep.Disable()
+		}
+		viewSel.Refresh()
+	}
 
 	btnUpload.OnTapped = func() {
 		fd := dialog.NewFileOpen(func(uc fyne.URIReadCloser, err error) {
@@ -60,8 +144,15 @@ func Run(_ context.Context, _ string) error {
 			inIV.Refresh()
 			outIV.Image = nil
 			outIV.Refresh()
+			srcImg = nil
 			binImg = nil
 			outImg = nil
+			skelPanel.reset()
+			trimPanel.reset()
+			overlayPanel.reset()
+			cutoutPanel.reset()
+			maskViews(true)
+			viewSel.SetSelected(viewContours)
 		}, w)
 		fd.SetFilter(storage.NewExtensionFileFilter(openExts))
 		fd.Show()
@@ -73,53 +164,138 @@ func Run(_ context.Context, _ string) error {
 			return
 		}
 		ctx := context.TODO()
+		opts := segPanel.Options()
+		src := inImg
+		status.SetText("")
+		if segPanel.deskew {
+			bin, err := imageutil.OtsuBinarize(ctx, inImg)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			var angle float64
+			src, angle, err = imageutil.Deskew(ctx, inImg, bin, imageutil.DefaultMaxSkew)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			status.SetText(fmt.Sprintf("Skew: %.2f°", angle))
+		}
+		srcImg = src
 		var err error
-		binImg, err = imageutil.OtsuBinarize(ctx, inImg)
-		if err != nil {
-			dialog.ShowError(err, w)
-			return
+		if opts.Mode == imageutil.ModeKMeans {
+			var res *imageutil.KMeansResult
+			res, err = imageutil.KMeansSegment(ctx, src, opts.K, opts.Seed)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			// the quantised colours are no binary mask
+			binImg = nil
+			outImg, err = imageutil.DrawClusterContours(ctx, src.Bounds(), res.Clusters)
+		} else {
+			binImg, err = imageutil.Segment(ctx, src, opts)
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			if segPanel.split {
+				binImg, err = imageutil.SplitTouching(ctx, binImg, segPanel.depth)
+				if err != nil {
+					dialog.ShowError(err, w)
+					return
+				}
+			}
+			outImg, err = imageutil.DrawScannedContours(ctx, binImg)
 		}
-		outImg, err = imageutil.DrawScannedContours(ctx, binImg)
 		if err != nil {
 			dialog.ShowError(err, w)
 			return
 		}
-		outIV.Image = outImg
-		outIV.Refresh()
+		skelPanel.reset()
+		trimPanel.reset()
+		overlayPanel.reset()
+		cutoutPanel.reset()
+		maskViews(binImg != nil)
+		viewSel.OnChanged(viewSel.Selected)
 	}
 
 	btnSave.OnTapped = func() {
-		if outImg == nil {
+		saveImg := shown()
+		if saveImg == nil {
 			dialog.ShowInformation("Nothing to save", "Run the pipeline first", w)
 			return
 		}
 		var labels []string
+		if binImg != nil {
+			labels = append(labels, "SVG", "DXF")
+		}
 		for ext := range encoders {
 			labels = append(labels, strings.ToUpper(ext[1:]))
 		}
+		sort.Strings(labels)
 		selectedExt := ".png"
+		embed := widget.NewCheck("Embed input image", nil)
+		embed.Disable()
+		mmPerPx := widget.NewEntry()
+		mmPerPx.SetText(strconv.FormatFloat(imageutil.DefaultDXFOptions.Scale, 'f', -1, 64))
+		mmPerPx.Disable()
 		fmtSel := widget.NewSelect(labels, func(s string) {
 			selectedExt = "." + strings.ToLower(s)
+			// SVG and DXF hold the traced contours instead of the shown image
+			if selectedExt == ".svg" {
+				embed.Enable()
+			} else {
+				embed.Disable()
+			}
+			if selectedExt == ".dxf" {
+				mmPerPx.Enable()
+			} else {
+				mmPerPx.Disable()
+			}
 		})
 		fmtSel.SetSelected("PNG")
 
-		dialog.ShowFileSave(func(uc fyne.URIWriteCloser, err error) {
-			if err != nil || uc == nil {
+		form := container.NewVBox(fmtSel, embed,
+			container.NewBorder(nil, nil, widget.NewLabel("DXF mm per pixel"), nil, mmPerPx))
+		dialog.ShowCustomConfirm("Save as", "Choose file", "Cancel", form, func(ok bool) {
+			if !ok {
 				return
 			}
-			defer uc.Close()
-			fName := withExt(uc.URI().Path(), selectedExt)
-			f, err := os.Create(fName)
-			if err != nil {
-				dialog.ShowError(err, w)
-				return
-			}
-			defer f.Close()
-			if enc, ok := encoders[selectedExt]; ok {
-				if err := enc(f, outImg); err != nil {
+			dialog.ShowFileSave(func(uc fyne.URIWriteCloser, err error) {
+				if err != nil || uc == nil {
+					return
+				}
+				defer uc.Close()
+				fName := withExt(uc.URI().Path(), selectedExt)
+				f, err := os.Create(fName)
+				if err != nil {
 					dialog.ShowError(err, w)
+					return
 				}
-			}
+				defer f.Close()
+				switch selectedExt {
+				case ".svg":
+					var bg image.Image
+					if embed.Checked {
+						bg = srcImg
+					}
+					if err := writeSVG(f, binImg, bg); err != nil {
+						dialog.ShowError(err, w)
+					}
+					return
+				case ".dxf":
+					if err := writeDXF(f, binImg, mmPerPx.Text); err != nil {
+						dialog.ShowError(err, w)
+					}
+					return
+				}
+				if enc, ok := encoders[selectedExt]; ok {
+					if err := enc(f, saveImg); err != nil {
+						dialog.ShowError(err, w)
+					}
+				}
+			}, w)
 		}, w)
 	}
 
@@ -131,9 +307,17 @@ func Run(_ context.Context, _ string) error {
 		ShowStepViewer(binImg, outImg)
 	}
 
+	btnDoc.OnTapped = func() {
+		if inImg == nil {
+			dialog.ShowInformation("No image", "Load an image first", w)
+			return
+		}
+		ShowDocumentWindow(inImg)
+	}
+
 	// --- centered buttons row ---
 	btnBox := container.NewHBox(
-		btnUpload, btnRun, btnSave, btnStep,
+		btnUpload, btnRun, btnSave, btnStep, btnDoc,
 	)
 
 	// --- full-width bottom bar: info left, centered buttons right ---
@@ -141,6 +325,7 @@ func Run(_ context.Context, _ string) error {
 		widget.NewButtonWithIcon("", theme.InfoIcon(), func() {
 			ShowInfoWindow(w)
 		}),
+		status,
 		layout.NewSpacer(),
 		btnBox,
 	)
@@ -148,15 +333,28 @@ func Run(_ context.Context, _ string) error {
 	grid := container.NewGridWithColumns(2,
 		container.NewBorder(
 			widget.NewLabel("Input"), nil, nil, nil,
-			inIV,
+			inView,
 		),
 		container.NewBorder(
-			widget.NewLabel("Output"), nil, nil, nil,
+			container.NewHBox(widget.NewLabel("Output"), layout.NewSpacer(), viewSel), nil, nil, nil,
 			outIV,
 		),
 	)
 
-	w.SetContent(container.NewBorder(nil, bottom, nil, nil, grid))
+	settings := container.NewVBox(
+		segPanel.content,
+		widget.NewSeparator(),
+		skelPanel.content,
+		widget.NewSeparator(),
+		trimPanel.content,
+		widget.NewSeparator(),
+		overlayPanel.content,
+		widget.NewSeparator(),
+		cutoutPanel.content,
+	)
+
+	w.SetContent(container.NewBorder(nil, bottom, nil,
+		container.NewVScroll(settings), grid))
 	w.ShowAndRun()
 	return nil
 }
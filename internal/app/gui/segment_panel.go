@@ -0,0 +1,161 @@
+package gui
+
+import (
+	"fmt"
+	"image/color"
+	"math"
+	"strconv"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/canvas"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// ---- segmentation settings ----
+
+// segmentPanel holds the controls that pick the segmentation mode and its
+// parameters, including the eyedropper for the reference colour.
+type segmentPanel struct {
+	mode      imageutil.SegmentMode
+	channel   imageutil.Channel
+	reference color.Color
+	distance  float64 // lab mode, delta E
+	hueTol    float64 // hsv mode, degrees
+	satTol    float64 // hsv mode, [0, 1]
+	valTol    float64 // hsv mode, [0, 1]
+	cutoff    float64 // alpha mode, 0-254
+	k         float64 // kmeans mode, number of clusters
+	sigma     float64 // canny mode, Gaussian sigma
+	split     bool    // separate touching objects
+	depth     float64 // split peak depth, pixels
+	deskew    bool    // straighten the page before segmenting
+
+	picking bool // next tap on the input image picks the reference colour
+
+	swatch   *canvas.Rectangle
+	refLabel *widget.Label
+	pickBtn  *widget.Button
+	content  fyne.CanvasObject
+}
+
+func newSegmentPanel() *segmentPanel {
+	p := &segmentPanel{
+		mode:      imageutil.ModeAuto,
+		reference: color.NRGBA{R: 255, A: 255},
+		distance:  20,
+		hueTol:    15,
+		satTol:    0.4,
+		valTol:    0.4,
+		cutoff:    imageutil.DefaultAlphaCutoff,
+		k:         4,
+		sigma:     imageutil.DefaultCannyOptions.Sigma,
+		depth:     imageutil.DefaultSplitDepth,
+	}
+
+	p.swatch = canvas.NewRectangle(p.reference)
+	p.swatch.SetMinSize(fyne.NewSize(24, 24))
+	p.refLabel = widget.NewLabel(hexColor(p.reference))
+	p.pickBtn = widget.NewButton("Pick colour", func() {
+		p.picking = true
+		p.pickBtn.SetText("Click input image…")
+	})
+
+	modeSel := widget.NewSelect(
+		[]string{string(imageutil.ModeAuto), string(imageutil.ModeOtsu),
+			string(imageutil.ModeHSV), string(imageutil.ModeLab),
+			string(imageutil.ModeChannel), string(imageutil.ModeAlpha),
+			string(imageutil.ModeKMeans), string(imageutil.ModeSobel),
+			string(imageutil.ModeScharr), string(imageutil.ModeCanny)},
+		func(s string) { p.mode = imageutil.SegmentMode(s) },
+	)
+	modeSel.SetSelected(string(p.mode))
+
+	chanSel := widget.NewSelect([]string{"r", "g", "b", "a"}, func(s string) {
+		p.channel, _ = imageutil.ParseChannel(s)
+	})
+	chanSel.SetSelected(p.channel.String())
+
+	p.content = container.NewVBox(
+		widget.NewLabel("Segmentation"),
+		widget.NewCheck("Deskew", func(on bool) { p.deskew = on }),
+		modeSel,
+		container.NewHBox(p.swatch, p.refLabel),
+		p.pickBtn,
+		labeledSlider("Lab distance", 1, 100, 1, &p.distance),
+		labeledSlider("Hue tolerance", 1, 180, 1, &p.hueTol),
+		labeledSlider("Saturation tolerance", 0, 1, 0.05, &p.satTol),
+		labeledSlider("Value tolerance", 0, 1, 0.05, &p.valTol),
+		widget.NewLabel("Channel"),
+		chanSel,
+		labeledSlider("Alpha cutoff", 0, 254, 1, &p.cutoff),
+		labeledSlider("Clusters", 2, 16, 1, &p.k),
+		labeledSlider("Canny sigma", 0, 5, 0.1, &p.sigma),
+		widget.NewCheck("Split touching objects", func(on bool) { p.split = on }),
+		labeledSlider("Split depth", 0.5, 10, 0.5, &p.depth),
+	)
+	return p
+}
+
+// Options returns the segmentation options for the current settings.
+func (p *segmentPanel) Options() imageutil.SegmentOptions {
+	return imageutil.SegmentOptions{
+		Mode:        p.mode,
+		HSV:         imageutil.HSVRangeAround(p.reference, p.hueTol, p.satTol, p.valTol),
+		Reference:   p.reference,
+		MaxDistance: p.distance,
+		Channel:     p.channel,
+		AlphaCutoff: uint8(p.cutoff),
+		K:           int(p.k),
+		Seed:        1,
+		Canny:       imageutil.CannyOptions{Sigma: p.sigma},
+	}
+}
+
+// Pick sets the reference colour if the eyedropper is armed.
+// It reports whether the colour was taken.
+func (p *segmentPanel) Pick(c color.Color) bool {
+	if !p.picking {
+		return false
+	}
+	p.picking = false
+	p.pickBtn.SetText("Pick colour")
+	p.reference = c
+	p.swatch.FillColor = c
+	p.swatch.Refresh()
+	p.refLabel.SetText(hexColor(c))
+	return true
+}
+
+// labeledSlider returns a slider bound to *v with a label showing its value.
+func labeledSlider(name string, min, max, step float64, v *float64) fyne.CanvasObject {
+	return labeledSliderNotify(name, min, max, step, v, nil)
+}
+
+// labeledSliderNotify is labeledSlider calling changed, if not nil, when
+// the user lets go of the slider.
+func labeledSliderNotify(name string, min, max, step float64, v *float64, changed func()) fyne.CanvasObject {
+	label := widget.NewLabel("")
+	setText := func() {
+		label.SetText(name + ": " + strconv.FormatFloat(math.Round(*v*100)/100, 'f', -1, 64))
+	}
+	s := widget.NewSlider(min, max)
+	s.Step = step
+	s.SetValue(*v)
+	s.OnChanged = func(f float64) {
+		*v = f
+		setText()
+	}
+	if changed != nil {
+		s.OnChangeEnded = func(float64) { changed() }
+	}
+	setText()
+	return container.NewVBox(label, s)
+}
+
+func hexColor(c color.Color) string {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return fmt.Sprintf("#%02x%02x%02x", n.R, n.G, n.B)
+}
@@ -0,0 +1,109 @@
+package gui
+
+import (
+	"context"
+	"encoding/json"
+	"image"
+	"os"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// output views selectable above the output image
+const (
+	viewContours = "Contours"
+	viewMask     = "Mask"
+	viewSkeleton = "Skeleton"
+)
+
+// ---- skeleton settings ----
+
+// skeletonPanel holds the thinning method and the last computed skeleton.
+type skeletonPanel struct {
+	method imageutil.ThinningMethod
+	image  image.Image // rendered skeleton graph, nil until computed
+	graph  *imageutil.SkeletonGraph
+
+	changed func() // called after a setting dropped the skeleton
+	content fyne.CanvasObject
+}
+
+func newSkeletonPanel(parent fyne.Window) *skeletonPanel {
+	p := &skeletonPanel{}
+
+	methodSel := widget.NewSelect(
+		[]string{imageutil.ZhangSuen.String(), imageutil.GuoHall.String()},
+		func(s string) {
+			p.method, _ = imageutil.ParseThinningMethod(s)
+			p.invalidate()
+		},
+	)
+	methodSel.SetSelected(p.method.String())
+
+	saveBtn := widget.NewButton("Save polylines", func() {
+		if p.graph == nil {
+			dialog.ShowInformation("No skeleton", "Show the Skeleton view first", parent)
+			return
+		}
+		dialog.ShowFileSave(func(uc fyne.URIWriteCloser, err error) {
+			if err != nil || uc == nil {
+				return
+			}
+			defer uc.Close()
+			f, err := os.Create(withExt(uc.URI().Path(), ".json"))
+			if err != nil {
+				dialog.ShowError(err, parent)
+				return
+			}
+			defer f.Close()
+			enc := json.NewEncoder(f)
+			enc.SetIndent("", "  ")
+			if err := enc.Encode(p.graph); err != nil {
+				dialog.ShowError(err, parent)
+			}
+		}, parent)
+	})
+
+	p.content = container.NewVBox(
+		widget.NewLabel("Skeleton"),
+		methodSel,
+		saveBtn,
+	)
+	return p
+}
+
+// compute thins the mask and builds the skeleton graph and its rendering.
+func (p *skeletonPanel) compute(ctx context.Context, binImg image.Image) error {
+	skel, err := imageutil.Skeletonize(ctx, binImg, p.method)
+	if err != nil {
+		return err
+	}
+	graph, err := imageutil.SkeletonToGraph(ctx, skel)
+	if err != nil {
+		return err
+	}
+	img, err := imageutil.DrawSkeletonGraph(ctx, binImg.Bounds(), graph)
+	if err != nil {
+		return err
+	}
+	p.graph, p.image = graph, img
+	return nil
+}
+
+// reset drops the skeleton of the previous run.
+func (p *skeletonPanel) reset() {
+	p.graph, p.image = nil, nil
+}
+
+// invalidate drops the skeleton after a settings change.
+func (p *skeletonPanel) invalidate() {
+	p.reset()
+	if p.changed != nil {
+		p.changed()
+	}
+}
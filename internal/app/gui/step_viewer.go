@@ -2,15 +2,22 @@ package gui
 
 import (
 	"context"
+	"errors"
+	"fmt"
 	"image"
 	"image/draw"
+	"os"
 	"strconv"
+	"strings"
 
 	"fyne.io/fyne/v2"
 	"fyne.io/fyne/v2/canvas"
 	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/dialog"
 	"fyne.io/fyne/v2/layout"
 	"fyne.io/fyne/v2/widget"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
 )
 
 // ---- step viewer for detailed showcase ----
@@ -84,6 +91,10 @@ func ShowStepViewer(binImg, scanImg image.Image) {
 		workCh <- n
 	}
 
+	exportBtn := widget.NewButton("Export animation", func() {
+		showAnimationExport(w, binImg)
+	})
+
 	exitBtn := widget.NewButton("Exit", func() {
 		close(workCh)
 		w.Close()
@@ -91,7 +102,7 @@ func ShowStepViewer(binImg, scanImg image.Image) {
 
 	w.SetContent(
 		container.NewBorder(
-			container.NewHBox(info, layout.NewSpacer(), exitBtn),
+			container.NewHBox(info, layout.NewSpacer(), exportBtn, exitBtn),
 			container.NewVBox(slider),
 			nil, nil,
 			imgCanvas,
@@ -100,3 +111,58 @@ func ShowStepViewer(binImg, scanImg image.Image) {
 	slider.SetValue(float64(h))
 	w.Show()
 }
+
+// showAnimationExport asks for the frame count, delay, scale and format,
+// then saves the scan of binImg as an animation.
+func showAnimationExport(w fyne.Window, binImg image.Image) {
+	d := imageutil.DefaultAnimationOptions
+	frames := widget.NewEntry()
+	frames.SetText(strconv.Itoa(d.Frames))
+	delay := widget.NewEntry()
+	delay.SetText(strconv.Itoa(d.Delay))
+	scale := widget.NewEntry()
+	scale.SetText(strconv.FormatFloat(d.Scale, 'f', -1, 64))
+	format := widget.NewSelect([]string{"GIF", "APNG"}, nil)
+	format.SetSelected("GIF")
+
+	items := []*widget.FormItem{
+		widget.NewFormItem("Format", format),
+		widget.NewFormItem("Frames", frames),
+		widget.NewFormItem("Delay, ms", delay),
+		widget.NewFormItem("Scale", scale),
+	}
+	dialog.ShowForm("Export animation", "Choose file", "Cancel", items, func(ok bool) {
+		if !ok {
+			return
+		}
+		opts := d
+		var err1, err2, err3 error
+		opts.Frames, err1 = strconv.Atoi(strings.TrimSpace(frames.Text))
+		opts.Delay, err2 = strconv.Atoi(strings.TrimSpace(delay.Text))
+		opts.Scale, err3 = strconv.ParseFloat(strings.TrimSpace(scale.Text), 64)
+		if err := errors.Join(err1, err2, err3); err != nil || opts.Frames < 1 || opts.Delay < 0 || opts.Scale <= 0 {
+			dialog.ShowError(fmt.Errorf("invalid animation settings: want a positive frame count, delay and scale"), w)
+			return
+		}
+		apng := format.Selected == "APNG"
+		ext := ".gif"
+		if apng {
+			ext = ".png"
+		}
+		dialog.ShowFileSave(func(uc fyne.URIWriteCloser, err error) {
+			if err != nil || uc == nil {
+				return
+			}
+			defer uc.Close()
+			f, err := os.Create(withExt(uc.URI().Path(), ext))
+			if err != nil {
+				dialog.ShowError(err, w)
+				return
+			}
+			defer f.Close()
+			if err := writeAnimation(f, binImg, apng, opts); err != nil {
+				dialog.ShowError(err, w)
+			}
+		}, w)
+	}, w)
+}
@@ -0,0 +1,84 @@
+package gui
+
+import (
+	"context"
+	"image"
+
+	"fyne.io/fyne/v2"
+	"fyne.io/fyne/v2/container"
+	"fyne.io/fyne/v2/widget"
+
+	"github.com/rifux/Go-BasicBorderScanner/internal/imageutil"
+)
+
+// view showing the original cropped to the objects
+const viewCropped = "Cropped"
+
+// aspect ratio choices of the crop
+var trimAspects = []string{"Free", "1:1", "4:3", "3:2", "16:9", "3:4", "2:3", "9:16"}
+
+// ---- crop settings ----
+
+// trimPanel holds the crop-to-object settings and the last cropped image.
+type trimPanel struct {
+	largest bool
+	padding float64
+	aspect  float64
+	image   image.Image // cropped original, nil until computed
+
+	changed func() // called after a setting dropped the crop
+	content fyne.CanvasObject
+}
+
+func newTrimPanel() *trimPanel {
+	p := &trimPanel{}
+
+	aspectSel := widget.NewSelect(trimAspects, func(s string) {
+		if s == trimAspects[0] {
+			p.aspect = 0
+		} else {
+			p.aspect, _ = imageutil.ParseAspect(s)
+		}
+		p.invalidate()
+	})
+	aspectSel.SetSelected(trimAspects[0])
+
+	p.content = container.NewVBox(
+		widget.NewLabel("Crop to objects"),
+		widget.NewCheck("Largest object only", func(on bool) {
+			p.largest = on
+			p.invalidate()
+		}),
+		labeledSliderNotify("Padding", 0, 200, 1, &p.padding, p.invalidate),
+		widget.NewLabel("Aspect ratio"),
+		aspectSel,
+	)
+	return p
+}
+
+// compute crops src to the objects of binImg.
+func (p *trimPanel) compute(ctx context.Context, src, binImg image.Image) error {
+	img, err := imageutil.Trim(ctx, src, binImg, imageutil.TrimOptions{
+		Largest: p.largest,
+		Padding: int(p.padding),
+		Aspect:  p.aspect,
+	})
+	if err != nil {
+		return err
+	}
+	p.image = img
+	return nil
+}
+
+// reset drops the crop of the previous run.
+func (p *trimPanel) reset() {
+	p.image = nil
+}
+
+// invalidate drops the crop after a settings change.
+func (p *trimPanel) invalidate() {
+	p.reset()
+	if p.changed != nil {
+		p.changed()
+	}
+}
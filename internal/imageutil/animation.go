@@ -0,0 +1,225 @@
+package imageutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"fmt"
+	"hash/crc32"
+	"image"
+	"image/color"
+	"image/draw"
+	"image/gif"
+	"image/png"
+	"io"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// -----------------------------------------------------------------------------
+// Scan animation
+// -----------------------------------------------------------------------------
+
+// AnimationOptions - settings for ScanFrames and the animation writers.
+type AnimationOptions struct {
+	Frames int     // frames from the first row to the finished scan
+	Delay  int     // per frame, in milliseconds
+	Scale  float64 // frame size relative to the image
+}
+
+// DefaultAnimationOptions - a four-second scan at full size.
+var DefaultAnimationOptions = AnimationOptions{Frames: 50, Delay: 80, Scale: 1}
+
+// animationPalette - colours of the scan frames: the binary image, contour
+// points, series still open and the scan line.
+var animationPalette = color.Palette{
+	color.White,
+	color.Black,
+	color.RGBA{R: 255, A: 255},
+	color.RGBA{G: 160, A: 255},
+	color.RGBA{B: 255, A: 255},
+}
+
+// ScanFrames renders the scanning algorithm of DrawScannedContours at
+// evenly spaced rows. Above the scan line (blue) each frame shows the
+// contour points found so far (red) and the black series still being
+// followed (green); below it the untouched binary image. The last frame
+// is the finished result.
+func ScanFrames(ctx context.Context, bin image.Image, opts AnimationOptions) ([]*image.Paletted, error) {
+	bounds := bin.Bounds()
+	h := bounds.Dy()
+	if opts.Frames < 1 || opts.Scale <= 0 || h == 0 {
+		return nil, fmt.Errorf("invalid animation: %d frames at scale %g", opts.Frames, opts.Scale)
+	}
+	n := min(opts.Frames, h+1)
+	rows := make(map[int]bool, n)
+	for k := range n - 1 {
+		rows[bounds.Min.Y+k*(h-1)/(n-1)] = true
+	}
+
+	size := image.Rect(0, 0,
+		max(1, int(math.Round(float64(bounds.Dx())*opts.Scale))),
+		max(1, int(math.Round(float64(h)*opts.Scale))))
+	background := image.NewPaletted(size, animationPalette)
+	xdraw.NearestNeighbor.Scale(background, size, bin, bounds, xdraw.Src, nil)
+
+	// markers are drawn straight into the frame, so thin rows survive
+	// shrinking and grow into blocks when enlarging
+	dot := max(1, int(math.Ceil(opts.Scale)))
+	at := func(x, y int) image.Point {
+		return image.Pt(
+			int(float64(x-bounds.Min.X)*opts.Scale),
+			int(float64(y-bounds.Min.Y)*opts.Scale))
+	}
+	plot := func(f *image.Paletted, p image.Point, idx uint8) {
+		q := at(p.X, p.Y)
+		draw.Draw(f, image.Rectangle{q, q.Add(image.Pt(dot, dot))}, &image.Uniform{animationPalette[idx]}, image.Point{}, draw.Src)
+	}
+	points := func(f *image.Paletted, contours map[int][]image.Point) {
+		for _, pts := range contours {
+			for _, p := range pts {
+				if p.In(bounds) {
+					plot(f, p, 2)
+				}
+			}
+		}
+	}
+
+	var frames []*image.Paletted
+	contours, err := scanContours(ctx, bin, func(y int, contours map[int][]image.Point, active []activeSer) {
+		if !rows[y] {
+			return
+		}
+		f := image.NewPaletted(size, animationPalette)
+		copy(f.Pix, background.Pix)
+		done := image.Rectangle{Max: image.Pt(size.Max.X, at(bounds.Min.X, y).Y+dot)}
+		draw.Draw(f, done, image.White, image.Point{}, draw.Src)
+		for _, as := range active {
+			for x := as.ser.startX; x <= as.ser.endX; x++ {
+				plot(f, image.Pt(x, y), 3)
+			}
+		}
+		points(f, contours)
+		if y+1 < bounds.Max.Y {
+			line := image.Rect(0, done.Max.Y, size.Max.X, done.Max.Y+1)
+			draw.Draw(f, line, &image.Uniform{animationPalette[4]}, image.Point{}, draw.Src)
+		}
+		frames = append(frames, f)
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	last := image.NewPaletted(size, animationPalette) // all white
+	points(last, contours)
+	return append(frames, last), ctx.Err()
+}
+
+// WriteGIFAnimation writes the frames as a looping GIF.
+func WriteGIFAnimation(w io.Writer, frames []*image.Paletted, delay int) error {
+	g := &gif.GIF{Image: frames, Delay: make([]int, len(frames))}
+	for i := range g.Delay {
+		g.Delay[i] = max(1, delay/10) // hundredths of a second
+	}
+	return gif.EncodeAll(w, g)
+}
+
+// WriteAPNG writes the frames as a looping animated PNG. Every frame is
+// encoded by image/png; its image data is then relabelled as APNG frame
+// data behind the chunks of the first frame.
+func WriteAPNG(w io.Writer, frames []*image.Paletted, delay int) error {
+	if len(frames) == 0 {
+		return fmt.Errorf("animation has no frames")
+	}
+	be := binary.BigEndian
+	var out bytes.Buffer
+	out.WriteString("\x89PNG\r\n\x1a\n")
+	chunk := func(typ string, data []byte) {
+		var b [4]byte
+		be.PutUint32(b[:], uint32(len(data)))
+		out.Write(b[:])
+		crc := crc32.NewIEEE()
+		crc.Write([]byte(typ))
+		crc.Write(data)
+		out.WriteString(typ)
+		out.Write(data)
+		be.PutUint32(b[:], crc.Sum32())
+		out.Write(b[:])
+	}
+
+	var seq uint32
+	size := frames[0].Bounds().Size()
+	for i, f := range frames {
+		var buf bytes.Buffer
+		if err := png.Encode(&buf, f); err != nil {
+			return err
+		}
+		chunks, err := pngChunks(buf.Bytes())
+		if err != nil {
+			return err
+		}
+
+		fctl := be.AppendUint32(nil, seq)
+		fctl = be.AppendUint32(fctl, uint32(size.X))
+		fctl = be.AppendUint32(fctl, uint32(size.Y))
+		fctl = be.AppendUint32(fctl, 0) // x offset
+		fctl = be.AppendUint32(fctl, 0) // y offset
+		fctl = be.AppendUint16(fctl, uint16(min(delay, math.MaxUint16)))
+		fctl = be.AppendUint16(fctl, 1000) // delay in milliseconds
+		fctl = append(fctl, 0, 0)          // no dispose, replace the area
+		seq++
+
+		fctlDone := false
+		for _, c := range chunks {
+			switch {
+			case c.typ == "IEND":
+			case c.typ != "IDAT":
+				// header, palette and the like: taken from the first frame
+				if i == 0 {
+					chunk(c.typ, c.data)
+				}
+			case i == 0:
+				if !fctlDone {
+					chunk("acTL", be.AppendUint32(be.AppendUint32(nil, uint32(len(frames))), 0)) // loop forever
+					chunk("fcTL", fctl)
+					fctlDone = true
+				}
+				chunk("IDAT", c.data)
+			default:
+				if !fctlDone {
+					chunk("fcTL", fctl)
+					fctlDone = true
+				}
+				chunk("fdAT", append(be.AppendUint32(nil, seq), c.data...))
+				seq++
+			}
+		}
+	}
+	chunk("IEND", nil)
+	_, err := w.Write(out.Bytes())
+	return err
+}
+
+// pngChunk - one chunk of an encoded PNG.
+type pngChunk struct {
+	typ  string
+	data []byte
+}
+
+// pngChunks splits an encoded PNG into its chunks.
+func pngChunks(b []byte) ([]pngChunk, error) {
+	if len(b) < 8 {
+		return nil, fmt.Errorf("PNG too short")
+	}
+	var chunks []pngChunk
+	for b = b[8:]; len(b) >= 12; {
+		n := int(binary.BigEndian.Uint32(b))
+		if len(b) < 12+n {
+			return nil, fmt.Errorf("PNG chunk overruns the data")
+		}
+		chunks = append(chunks, pngChunk{string(b[4:8]), b[8 : 8+n]})
+		b = b[12+n:]
+	}
+	return chunks, nil
+}
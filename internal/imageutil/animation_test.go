@@ -0,0 +1,111 @@
+package imageutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"image/gif"
+	"image/png"
+	"testing"
+)
+
+func TestScanFrames(t *testing.T) {
+	bin := filledShape(func(p PointF) bool {
+		return p.Dist(PointF{100, 100}) < 60
+	})
+	opts := AnimationOptions{Frames: 10, Delay: 50, Scale: 0.5}
+	frames, err := ScanFrames(context.Background(), bin, opts)
+	if err != nil {
+		t.Fatalf("got error while rendering frames:\n%s", err.Error())
+	}
+	if len(frames) != 10 {
+		t.Fatalf("got %d frames, want 10", len(frames))
+	}
+	for _, f := range frames {
+		if s := f.Bounds().Size(); s.X != 100 || s.Y != 100 {
+			t.Fatalf("got frame size %v, want 100x100", s)
+		}
+	}
+
+	// halfway down, the disc is still black below the scan line and
+	// its open series are green on it
+	mid := frames[5]
+	if c := mid.ColorIndexAt(50, 70); c != 1 {
+		t.Errorf("got colour %d below the scan line, want black", c)
+	}
+	green := false
+	for x := 0; x < 100; x++ {
+		for y := 0; y < 100; y++ {
+			if mid.ColorIndexAt(x, y) == 3 {
+				green = true
+			}
+		}
+	}
+	if !green {
+		t.Errorf("got no open series halfway through the scan")
+	}
+
+	// at full size the last frame is the finished result
+	full, err := DrawScannedContours(context.Background(), bin)
+	if err != nil {
+		t.Fatalf("got error while scanning:\n%s", err.Error())
+	}
+	one, err := ScanFrames(context.Background(), bin, AnimationOptions{Frames: 1, Scale: 1})
+	if err != nil {
+		t.Fatalf("got error while rendering frames:\n%s", err.Error())
+	}
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			want := animationPalette.Index(full.At(x, y))
+			if got := int(one[0].ColorIndexAt(x, y)); got != want {
+				t.Fatalf("got colour %d at (%d,%d) of the last frame, want %d", got, x, y, want)
+			}
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGIFAnimation(&buf, frames, opts.Delay); err != nil {
+		t.Fatalf("got error while writing GIF:\n%s", err.Error())
+	}
+	g, err := gif.DecodeAll(&buf)
+	if err != nil {
+		t.Fatalf("got error while decoding GIF:\n%s", err.Error())
+	}
+	if len(g.Image) != 10 || g.Delay[0] != 5 {
+		t.Errorf("got %d GIF frames with delay %d, want 10 with delay 5", len(g.Image), g.Delay[0])
+	}
+
+	buf.Reset()
+	if err := WriteAPNG(&buf, frames, opts.Delay); err != nil {
+		t.Fatalf("got error while writing APNG:\n%s", err.Error())
+	}
+	data := buf.Bytes()
+	if _, err := png.Decode(bytes.NewReader(data)); err != nil {
+		t.Fatalf("got error while decoding APNG default image:\n%s", err.Error())
+	}
+	chunks, err := pngChunks(data)
+	if err != nil {
+		t.Fatalf("got error while reading APNG chunks:\n%s", err.Error())
+	}
+	count := map[string]int{}
+	var seq []uint32
+	for _, c := range chunks {
+		count[c.typ]++
+		switch c.typ {
+		case "acTL":
+			if n := binary.BigEndian.Uint32(c.data); n != 10 {
+				t.Errorf("got acTL with %d frames, want 10", n)
+			}
+		case "fcTL", "fdAT":
+			seq = append(seq, binary.BigEndian.Uint32(c.data))
+		}
+	}
+	if count["acTL"] != 1 || count["fcTL"] != 10 || count["fdAT"] < 9 || count["IHDR"] != 1 {
+		t.Errorf("got APNG chunks %v", count)
+	}
+	for i, s := range seq {
+		if s != uint32(i) {
+			t.Fatalf("got sequence numbers %v, want 0, 1, 2, ...", seq)
+		}
+	}
+}
@@ -9,21 +9,71 @@ import (
 // OtsuBinarize applies Otsu's method to binarize an image.
 // It automatically determines the optimal threshold to separate pixels into foreground and background.
 func OtsuBinarize(ctx context.Context, src image.Image) (image.Image, error) {
+	return otsuBinarizeFunc(ctx, src, func(c color.Color) uint8 {
+		return color.GrayModel.Convert(c).(color.Gray).Y
+	})
+}
+
+// OtsuLevel returns the luminance threshold OtsuBinarize picks for src:
+// pixels above it become background.
+func OtsuLevel(ctx context.Context, src image.Image) (int, error) {
+	return otsuLevelFunc(ctx, src, func(c color.Color) uint8 {
+		return color.GrayModel.Convert(c).(color.Gray).Y
+	})
+}
+
+// otsuLevelFunc returns Otsu's threshold for the 8-bit values returned by
+// level for every pixel of src.
+func otsuLevelFunc(ctx context.Context, src image.Image, level func(color.Color) uint8) (int, error) {
 	bounds := src.Bounds()
-	out := image.NewGray(bounds)
 
-	// Step 1: Compute the grayscale histogram.
+	// Step 1: Compute the histogram.
 	histogram := make([]int, 256)
 	totalPixels := 0
 	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
 		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			gray := color.GrayModel.Convert(src.At(x, y)).(color.Gray)
-			histogram[gray.Y]++
+			histogram[level(src.At(x, y))]++
 			totalPixels++
 		}
 	}
 
-	// Step 2: Calculate the total sum of the histogram.
+	// Steps 2-3: Find the optimal threshold.
+	return otsuThreshold(ctx, histogram, totalPixels)
+}
+
+// otsuBinarizeFunc binarizes src with Otsu's method applied to the 8-bit
+// values returned by level for every pixel.
+func otsuBinarizeFunc(ctx context.Context, src image.Image, level func(color.Color) uint8) (image.Image, error) {
+	bounds := src.Bounds()
+	out := image.NewGray(bounds)
+
+	threshold, err := otsuLevelFunc(ctx, src, level)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 4: Apply the threshold to create the binary image.
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		// Check for context cancellation.
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if level(src.At(x, y)) > uint8(threshold) {
+				out.SetGray(x, y, color.Gray{Y: 255}) // Foreground (white)
+			} else {
+				out.SetGray(x, y, color.Gray{Y: 0}) // Background (black)
+			}
+		}
+	}
+
+	return out, ctx.Err()
+}
+
+// otsuThreshold returns the threshold maximizing the between-class variance
+// of a 256-bin histogram holding totalPixels samples.
+func otsuThreshold(ctx context.Context, histogram []int, totalPixels int) (int, error) {
+	// Calculate the total sum of the histogram.
 	var sum float64
 	for i, h := range histogram {
 		sum += float64(i) * float64(h)
@@ -34,11 +84,10 @@ func OtsuBinarize(ctx context.Context, src image.Image) (image.Image, error) {
 	var maxVariance float64
 	var threshold int
 
-	// Step 3: Find the optimal threshold.
-	for t := 0; t < 256; t++ {
+	for t := 0; t < len(histogram); t++ {
 		// Check for context cancellation.
 		if err := ctx.Err(); err != nil {
-			return nil, err
+			return 0, err
 		}
 
 		wB += histogram[t] // Weight of the background
@@ -65,21 +114,5 @@ func OtsuBinarize(ctx context.Context, src image.Image) (image.Image, error) {
 		}
 	}
 
-	// Step 4: Apply the threshold to create the binary image.
-	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
-		// Check for context cancellation.
-		if err := ctx.Err(); err != nil {
-			return nil, err
-		}
-		for x := bounds.Min.X; x < bounds.Max.X; x++ {
-			gray := color.GrayModel.Convert(src.At(x, y)).(color.Gray)
-			if gray.Y > uint8(threshold) {
-				out.SetGray(x, y, color.Gray{Y: 255}) // Foreground (white)
-			} else {
-				out.SetGray(x, y, color.Gray{Y: 0}) // Background (black)
-			}
-		}
-	}
-
-	return out, ctx.Err()
+	return threshold, nil
 }
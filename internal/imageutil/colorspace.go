@@ -0,0 +1,95 @@
+package imageutil
+
+import (
+	"image/color"
+	"math"
+)
+
+// -----------------------------------------------------------------------------
+// Colour space conversions
+// -----------------------------------------------------------------------------
+
+// HSV - colour in the hue/saturation/value model.
+// H is in degrees [0, 360), S and V are in [0, 1].
+type HSV struct {
+	H, S, V float64
+}
+
+// Lab - colour in the CIE L*a*b* model (D65 white point).
+type Lab struct {
+	L, A, B float64
+}
+
+// ToHSV converts any colour to HSV. Alpha is ignored.
+func ToHSV(c color.Color) HSV {
+	r, g, b := rgb01(c)
+	maxC := math.Max(r, math.Max(g, b))
+	minC := math.Min(r, math.Min(g, b))
+	delta := maxC - minC
+
+	var h float64
+	switch {
+	case delta == 0:
+		h = 0
+	case maxC == r:
+		h = 60 * math.Mod((g-b)/delta, 6)
+	case maxC == g:
+		h = 60 * ((b-r)/delta + 2)
+	default:
+		h = 60 * ((r-g)/delta + 4)
+	}
+	if h < 0 {
+		h += 360
+	}
+
+	var s float64
+	if maxC > 0 {
+		s = delta / maxC
+	}
+	return HSV{H: h, S: s, V: maxC}
+}
+
+// ToLab converts any colour to CIE L*a*b*. Alpha is ignored.
+func ToLab(c color.Color) Lab {
+	r, g, b := rgb01(c)
+	r, g, b = srgbToLinear(r), srgbToLinear(g), srgbToLinear(b)
+
+	// linear sRGB -> XYZ, normalised by the D65 white point
+	x := (0.4124564*r + 0.3575761*g + 0.1804375*b) / 0.95047
+	y := 0.2126729*r + 0.7151522*g + 0.0721750*b
+	z := (0.0193339*r + 0.1191920*g + 0.9503041*b) / 1.08883
+
+	fx, fy, fz := labF(x), labF(y), labF(z)
+	return Lab{
+		L: 116*fy - 16,
+		A: 500 * (fx - fy),
+		B: 200 * (fy - fz),
+	}
+}
+
+// Distance returns the CIE76 colour difference (Euclidean distance in Lab).
+func (l Lab) Distance(o Lab) float64 {
+	dl, da, db := l.L-o.L, l.A-o.A, l.B-o.B
+	return math.Sqrt(dl*dl + da*da + db*db)
+}
+
+// rgb01 returns the non-premultiplied RGB components of c in [0, 1].
+func rgb01(c color.Color) (r, g, b float64) {
+	n := color.NRGBAModel.Convert(c).(color.NRGBA)
+	return float64(n.R) / 255, float64(n.G) / 255, float64(n.B) / 255
+}
+
+func srgbToLinear(v float64) float64 {
+	if v <= 0.04045 {
+		return v / 12.92
+	}
+	return math.Pow((v+0.055)/1.055, 2.4)
+}
+
+func labF(t float64) float64 {
+	const delta = 6.0 / 29.0
+	if t > delta*delta*delta {
+		return math.Cbrt(t)
+	}
+	return t/(3*delta*delta) + 4.0/29.0
+}
@@ -0,0 +1,181 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+)
+
+// -----------------------------------------------------------------------------
+// Contour extraction
+// -----------------------------------------------------------------------------
+
+// Contour - one closed border of a connected region of black pixels.
+type Contour struct {
+	ID     int           // unique contour ID, starting at 1
+	Parent int           // ID of the enclosing contour, 0 for top-level contours
+	Hole   bool          // true - border of a hole, false - outer border of an object
+	Points []image.Point // border pixels in tracing order
+}
+
+// neighbour offsets, clockwise on screen starting from east
+var dirs8 = [8]image.Point{
+	{1, 0}, {1, 1}, {0, 1}, {-1, 1}, {-1, 0}, {-1, -1}, {0, -1}, {1, -1},
+}
+
+// dirTo returns the index in dirs8 of the step from a to its neighbour b.
+func dirTo(a, b image.Point) int {
+	d := b.Sub(a)
+	for i, o := range dirs8 {
+		if o == d {
+			return i
+		}
+	}
+	return 0
+}
+
+// FindContours traces the borders of all 8-connected black regions of a
+// binary image (Suzuki-Abe border following). Outer borders and hole borders
+// are both returned; Parent links them into a hierarchy.
+func FindContours(ctx context.Context, bin image.Image) ([]Contour, error) {
+	bounds := bin.Bounds()
+	w, h := bounds.Dx()+2, bounds.Dy()+2
+
+	// label map with a one-pixel background frame:
+	// 0 - background, 1 - unvisited object, +-n - border n (NBD)
+	f := make([]int, w*h)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if isBlack(bin, x, y) {
+				f[(y-bounds.Min.Y+1)*w+(x-bounds.Min.X+1)] = 1
+			}
+		}
+	}
+	at := func(p image.Point) int { return f[p.Y*w+p.X] }
+
+	var contours []Contour
+	nbd := 1 // the frame is border 1
+
+	for y := 1; y < h-1; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		lnbd := 1
+		for x := 1; x < w-1; x++ {
+			v := f[y*w+x]
+			if v == 0 {
+				continue
+			}
+
+			var from image.Point
+			hole := false
+			switch {
+			case v == 1 && f[y*w+x-1] == 0: // outer border start
+				from = image.Pt(x-1, y)
+			case v >= 1 && f[y*w+x+1] == 0: // hole border start
+				from = image.Pt(x+1, y)
+				hole = true
+				if v > 1 {
+					lnbd = v
+				}
+			default:
+				if v != 1 {
+					lnbd = abs(v)
+				}
+				continue
+			}
+
+			nbd++
+			c := Contour{ID: nbd - 1, Hole: hole, Parent: parentOf(contours, lnbd-1, hole)}
+			c.Points = traceBorder(f, w, image.Pt(x, y), from, nbd, at)
+			for i := range c.Points {
+				c.Points[i] = c.Points[i].Add(bounds.Min).Sub(image.Pt(1, 1))
+			}
+			contours = append(contours, c)
+
+			if v := f[y*w+x]; v != 1 {
+				lnbd = abs(v)
+			}
+		}
+	}
+	return contours, ctx.Err()
+}
+
+// parentOf determines the parent of a new border from the last border met
+// on the same row (id 0 is the image frame, which counts as a hole).
+func parentOf(contours []Contour, last int, hole bool) int {
+	if last == 0 {
+		return 0
+	}
+	l := contours[last-1]
+	if l.Hole == hole {
+		return l.Parent
+	}
+	return l.ID
+}
+
+// traceBorder follows one border starting at start, whose zero neighbour is
+// from, marking visited pixels with nbd. It returns the border pixels.
+func traceBorder(f []int, w int, start, from image.Point, nbd int, at func(image.Point) int) []image.Point {
+	// 3.1 clockwise search for the first non-zero neighbour
+	d0 := dirTo(start, from)
+	first := image.Point{}
+	found := false
+	for k := 0; k < 8; k++ {
+		p := start.Add(dirs8[(d0+k)%8])
+		if at(p) != 0 {
+			first, found = p, true
+			break
+		}
+	}
+	if !found { // isolated pixel
+		f[start.Y*w+start.X] = -nbd
+		return []image.Point{start}
+	}
+
+	var pts []image.Point
+	prev, cur := first, start
+	for {
+		// 3.3 counter-clockwise search around cur, starting after prev
+		d := dirTo(cur, prev)
+		eastZero := false
+		var next image.Point
+		for k := 1; k <= 8; k++ {
+			dd := (d - k + 16) % 8
+			p := cur.Add(dirs8[dd])
+			if at(p) != 0 {
+				next = p
+				break
+			}
+			if dd == 0 {
+				eastZero = true
+			}
+		}
+
+		// 3.4 mark the current pixel
+		switch i := cur.Y*w + cur.X; {
+		case eastZero:
+			f[i] = -nbd
+		case f[i] == 1:
+			f[i] = nbd
+		}
+		pts = append(pts, cur)
+
+		// 3.5 back at the start going the same way - done
+		if next == start && cur == first {
+			return pts
+		}
+		prev, cur = cur, next
+	}
+}
+
+func isBlack(img image.Image, x, y int) bool {
+	return color.GrayModel.Convert(img.At(x, y)).(color.Gray).Y == 0
+}
+
+func abs(v int) int {
+	if v < 0 {
+		return -v
+	}
+	return v
+}
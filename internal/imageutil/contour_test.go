@@ -0,0 +1,61 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// binaryImage builds a mask from rows of '#' (object) and '.' (background).
+func binaryImage(rows ...string) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, len(rows[0]), len(rows)))
+	for y, row := range rows {
+		for x, ch := range row {
+			v := uint8(255)
+			if ch == '#' {
+				v = 0
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+func TestFindContours(t *testing.T) {
+	bin := binaryImage(
+		"..........",
+		".######...",
+		".#....#...",
+		".#.##.#.#.",
+		".#....#...",
+		".######...",
+		"..........",
+	)
+	contours, err := FindContours(context.Background(), bin)
+	if err != nil {
+		t.Fatalf("got error while tracing contours:\n%s", err.Error())
+	}
+
+	// ring outer border, ring hole, island inside the hole, lone pixel
+	want := []struct {
+		parent int
+		hole   bool
+		points int
+	}{
+		{0, false, 18},
+		{1, true, 14},
+		{2, false, 2},
+		{0, false, 1},
+	}
+	if len(contours) != len(want) {
+		t.Fatalf("got %d contours, want %d: %+v", len(contours), len(want), contours)
+	}
+	for i, w := range want {
+		c := contours[i]
+		if c.ID != i+1 || c.Parent != w.parent || c.Hole != w.hole || len(c.Points) != w.points {
+			t.Fatalf("contour %d: got id=%d parent=%d hole=%v points=%d, want parent=%d hole=%v points=%d",
+				i, c.ID, c.Parent, c.Hole, len(c.Points), w.parent, w.hole, w.points)
+		}
+	}
+}
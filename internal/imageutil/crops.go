@@ -0,0 +1,135 @@
+package imageutil
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"image"
+	"image/draw"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Per-object crops
+// -----------------------------------------------------------------------------
+
+// CropOptions - settings for CropObjects.
+type CropOptions struct {
+	Padding int     // pixels added around each bounding box, clipped to the image; not negative
+	Mask    bool    // make everything outside the object, and its holes, transparent
+	MinArea float64 // skip objects with a smaller area, in square pixels
+}
+
+// ObjectCrop - one object cut out of the source image.
+type ObjectCrop struct {
+	Metrics ContourMetrics  // of the outer contour
+	Rect    image.Rectangle // crop in source pixel coordinates
+	Image   image.Image     // the crop, starting at (0, 0)
+}
+
+// CropObjects cuts the bounding box of every object (outer contour) out of
+// src, in contour order.
+func CropObjects(ctx context.Context, src image.Image, contours []Contour, opts CropOptions) ([]ObjectCrop, error) {
+	if opts.Padding < 0 {
+		return nil, fmt.Errorf("invalid crop padding %d", opts.Padding)
+	}
+	ms, err := MeasureContours(ctx, contours)
+	if err != nil {
+		return nil, err
+	}
+	holes := make(map[int][]Contour)
+	for _, c := range contours {
+		if c.Hole {
+			holes[c.Parent] = append(holes[c.Parent], c)
+		}
+	}
+
+	var crops []ObjectCrop
+	for i, c := range contours {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		m := ms[i]
+		if c.Hole || m.Area < opts.MinArea {
+			continue
+		}
+		r := m.Rect().Inset(-opts.Padding).Intersect(src.Bounds())
+		out := image.NewNRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+		draw.Draw(out, out.Bounds(), src, r.Min, draw.Src)
+		if opts.Mask {
+			alpha, err := ContourMask(ctx, r, append([]Contour{c}, holes[c.ID]...), 0)
+			if err != nil {
+				return nil, err
+			}
+			for y := 0; y < r.Dy(); y++ {
+				for x := 0; x < r.Dx(); x++ {
+					k := out.PixOffset(x, y) + 3
+					a := alpha.AlphaAt(r.Min.X+x, r.Min.Y+y).A
+					out.Pix[k] = uint8(uint32(out.Pix[k]) * uint32(a) / 255)
+				}
+			}
+		}
+		crops = append(crops, ObjectCrop{Metrics: m, Rect: r, Image: out})
+	}
+	return crops, ctx.Err()
+}
+
+// CropRecord - one line of the crop manifest.
+type CropRecord struct {
+	File        string    `json:"file"`
+	ID          int       `json:"id"`
+	Crop        [4]int    `json:"crop"` // x, y, width, height in the source image
+	BBox        [4]int    `json:"bbox"` // object bounding box in the source image
+	Area        float64   `json:"area"`
+	Perimeter   float64   `json:"perimeter"`
+	Circularity float64   `json:"circularity"`
+	Shape       ShapeKind `json:"shape"`
+}
+
+// NewCropRecord describes crop saved as file.
+func NewCropRecord(file string, crop ObjectCrop) CropRecord {
+	m, r := crop.Metrics, crop.Rect
+	return CropRecord{
+		File:        file,
+		ID:          m.ID,
+		Crop:        [4]int{r.Min.X, r.Min.Y, r.Dx(), r.Dy()},
+		BBox:        [4]int{m.X, m.Y, m.Width, m.Height},
+		Area:        m.Area,
+		Perimeter:   m.Perimeter,
+		Circularity: m.Circularity,
+		Shape:       m.Shape,
+	}
+}
+
+// WriteCropManifest writes the records as "json" (an object holding the
+// source image name and the crops) or "csv" (one row per crop).
+func WriteCropManifest(w io.Writer, source string, records []CropRecord, format string) error {
+	switch strings.ToLower(format) {
+	case "json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(struct {
+			Source string       `json:"source"`
+			Crops  []CropRecord `json:"crops"`
+		}{source, records})
+	case "csv":
+		cw := csv.NewWriter(w)
+		cw.Write([]string{"source", "file", "id", "crop_x", "crop_y", "crop_width", "crop_height",
+			"x", "y", "width", "height", "area", "perimeter", "circularity", "shape"})
+		ff := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+		for _, r := range records {
+			cw.Write([]string{
+				source, r.File, strconv.Itoa(r.ID),
+				strconv.Itoa(r.Crop[0]), strconv.Itoa(r.Crop[1]), strconv.Itoa(r.Crop[2]), strconv.Itoa(r.Crop[3]),
+				strconv.Itoa(r.BBox[0]), strconv.Itoa(r.BBox[1]), strconv.Itoa(r.BBox[2]), strconv.Itoa(r.BBox[3]),
+				ff(r.Area), ff(r.Perimeter), ff(r.Circularity), string(r.Shape),
+			})
+		}
+		cw.Flush()
+		return cw.Error()
+	}
+	return fmt.Errorf("unsupported manifest format %q (want json or csv)", format)
+}
@@ -0,0 +1,90 @@
+package imageutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestCropObjects(t *testing.T) {
+	// ring with a dot inside its hole, and a square
+	mask := filledShape(func(p PointF) bool {
+		d := p.Dist(PointF{70, 70})
+		square := p.X > 150 && p.X < 180 && p.Y > 150 && p.Y < 180
+		return (d < 50 && d > 25) || d < 8 || square
+	})
+	contours, err := FindContours(context.Background(), mask)
+	if err != nil {
+		t.Fatalf("got error while finding contours:\n%s", err.Error())
+	}
+
+	if _, err := CropObjects(context.Background(), mask, contours, CropOptions{Padding: -1}); err == nil {
+		t.Fatalf("got no error for negative padding")
+	}
+	crops, err := CropObjects(context.Background(), mask, contours, CropOptions{Padding: 3, Mask: true, MinArea: 500})
+	if err != nil {
+		t.Fatalf("got error while cropping objects:\n%s", err.Error())
+	}
+	if len(crops) != 2 {
+		t.Fatalf("got %d crops, want 2 (the dot is below MinArea)", len(crops))
+	}
+	for _, c := range crops {
+		want := c.Metrics.Rect().Inset(-3)
+		if c.Rect != want {
+			t.Errorf("got crop %v for object %d, want %v", c.Rect, c.Metrics.ID, want)
+		}
+		if b := c.Image.Bounds(); b != image.Rect(0, 0, want.Dx(), want.Dy()) {
+			t.Errorf("got image bounds %v for object %d, want %v at the origin", b, c.Metrics.ID, want.Size())
+		}
+	}
+
+	alphaAt := func(img image.Image, p image.Point) uint8 {
+		return color.NRGBAModel.Convert(img.At(p.X, p.Y)).(color.NRGBA).A
+	}
+	ring := crops[0]
+	at := func(x, y int) image.Point { return image.Pt(x, y).Sub(ring.Rect.Min) }
+	if a := alphaAt(ring.Image, at(70+37, 70)); a != 255 {
+		t.Errorf("got alpha %d on the ring, want opaque", a)
+	}
+	if a := alphaAt(ring.Image, at(70, 70)); a != 0 {
+		t.Errorf("got alpha %d on the dot inside the hole, want transparent", a)
+	}
+	if a := alphaAt(ring.Image, at(70+55, 70)); a != 0 {
+		t.Errorf("got alpha %d outside the ring, want transparent", a)
+	}
+
+	records := make([]CropRecord, len(crops))
+	for i, c := range crops {
+		records[i] = NewCropRecord("crop.png", c)
+	}
+	var buf bytes.Buffer
+	if err := WriteCropManifest(&buf, "in.png", records, "json"); err != nil {
+		t.Fatalf("got error while writing JSON manifest:\n%s", err.Error())
+	}
+	var manifest struct {
+		Source string       `json:"source"`
+		Crops  []CropRecord `json:"crops"`
+	}
+	if err := json.Unmarshal(buf.Bytes(), &manifest); err != nil {
+		t.Fatalf("got error while parsing JSON manifest:\n%s", err.Error())
+	}
+	if manifest.Source != "in.png" || len(manifest.Crops) != 2 || manifest.Crops[1].Crop[2] != crops[1].Rect.Dx() {
+		t.Errorf("got manifest %+v", manifest)
+	}
+
+	buf.Reset()
+	if err := WriteCropManifest(&buf, "in.png", records, "csv"); err != nil {
+		t.Fatalf("got error while writing CSV manifest:\n%s", err.Error())
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("got error while parsing CSV manifest:\n%s", err.Error())
+	}
+	if len(rows) != 3 {
+		t.Errorf("got %d CSV rows, want a header and 2 crops", len(rows))
+	}
+}
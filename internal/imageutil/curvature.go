@@ -0,0 +1,277 @@
+package imageutil
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"strconv"
+)
+
+// -----------------------------------------------------------------------------
+// Curvature and corners
+// -----------------------------------------------------------------------------
+
+// CornerOptions - settings for FindCorners.
+type CornerOptions struct {
+	Span         int     // points on each side of the k-cosine angle
+	MaxAngle     float64 // angles (degrees) sharper than this are corners
+	Sigma        float64 // Gaussian smoothing of the curvature, in points
+	MinCurvature float64 // bends weaker than this (1/pixels) are no inflections
+}
+
+// DefaultCornerOptions - defaults for FindCorners.
+var DefaultCornerOptions = CornerOptions{Span: 5, MaxAngle: 135, Sigma: 3, MinCurvature: 0.02}
+
+// Corner - sharp bend of a contour.
+type Corner struct {
+	Index  int // position in the contour's point list
+	Point  image.Point
+	Angle  float64 // angle between the arms in degrees, 180 is straight
+	Convex bool    // false for corners pointing into the object
+}
+
+// ContourCorners - corners and inflection points of one contour.
+type ContourCorners struct {
+	ID          int
+	Hole        bool
+	Corners     []Corner
+	Inflections []image.Point // where the outline changes from convex to concave
+}
+
+// KCosine returns the angle in degrees at every point of the closed contour
+// between the points span steps before and after it.
+func KCosine(pts []PointF, span int) []float64 {
+	n := len(pts)
+	angle := make([]float64, n)
+	if n < 3 {
+		return angle
+	}
+	span = max(1, min(span, (n-1)/2))
+	for i := range pts {
+		angle[i] = cornerAngle(pts[(i-span+n)%n], pts[i], pts[(i+span)%n])
+	}
+	return angle
+}
+
+// Curvature returns the signed curvature (1/pixels) at every point of the
+// closed contour, computed from Gaussian-smoothed derivatives. It is
+// positive where the contour bends the same way it winds.
+func Curvature(pts []PointF, sigma float64) []float64 {
+	n := len(pts)
+	curv := make([]float64, n)
+	if n < 3 {
+		return curv
+	}
+	g1, g2 := gaussianDerivatives(sigma, n)
+	r := len(g1) / 2
+	for i := range pts {
+		// offsets from the point itself: the truncated kernels do not sum
+		// to exactly zero
+		var d1, d2 PointF
+		for k := -r; k <= r; k++ {
+			p := pts[((i+k)%n+n)%n].Sub(pts[i])
+			d1 = d1.Add(p.Mul(g1[k+r]))
+			d2 = d2.Add(p.Mul(g2[k+r]))
+		}
+		if l := d1.Len(); l > 0 {
+			curv[i] = d1.Cross(d2) / (l * l * l)
+		}
+	}
+	if SignedArea(pts) < 0 {
+		for i := range curv {
+			curv[i] = -curv[i]
+		}
+	}
+	return curv
+}
+
+// gaussianDerivatives returns the first and second derivative of a
+// Gaussian as convolution kernels, at most n taps long.
+func gaussianDerivatives(sigma float64, n int) (g1, g2 []float64) {
+	sigma = math.Max(sigma, 0.5)
+	r := min(int(math.Ceil(3*sigma)), (n-1)/2)
+	g1 = make([]float64, 2*r+1)
+	g2 = make([]float64, 2*r+1)
+	s2 := sigma * sigma
+	norm := 1 / (math.Sqrt(2*math.Pi) * sigma)
+	for k := -r; k <= r; k++ {
+		x := float64(k)
+		g := norm * math.Exp(-x*x/(2*s2))
+		// convolution flips the kernel, hence the signs
+		g1[k+r] = x / s2 * g
+		g2[k+r] = (x*x/s2 - 1) / s2 * g
+	}
+	return g1, g2
+}
+
+// findCorners returns the indices of the points whose angle is below
+// maxAngle degrees, keeping only the sharpest point within span of each.
+func findCorners(angle []float64, span int, maxAngle float64) []int {
+	n := len(angle)
+	span = min(span, (n-1)/2)
+	if span < 1 {
+		return nil
+	}
+	var corners []int
+	for i := range angle {
+		if angle[i] >= maxAngle {
+			continue
+		}
+		// on a plateau of equal angles the first point wins
+		sharpest := true
+		for d := 1; d <= span; d++ {
+			if angle[(i-d+n)%n] <= angle[i] || angle[(i+d)%n] < angle[i] {
+				sharpest = false
+				break
+			}
+		}
+		if sharpest {
+			corners = append(corners, i)
+		}
+	}
+	return corners
+}
+
+// FindCorners returns the corners of a closed contour: the sharpest points
+// of every stretch whose k-cosine angle is below opts.MaxAngle.
+func FindCorners(points []image.Point, opts CornerOptions) []Corner {
+	pts := toPointsF(points)
+	if len(pts) < 3 {
+		return nil
+	}
+	angle := KCosine(pts, opts.Span)
+	orient := SignedArea(pts)
+	n, span := len(pts), max(1, min(opts.Span, (len(pts)-1)/2))
+
+	var corners []Corner
+	for _, i := range findCorners(angle, opts.Span, opts.MaxAngle) {
+		a, b, c := pts[(i-span+n)%n], pts[i], pts[(i+span)%n]
+		turn := b.Sub(a).Cross(c.Sub(b))
+		corners = append(corners, Corner{
+			Index:  i,
+			Point:  points[i],
+			Angle:  angle[i],
+			Convex: turn*orient >= 0,
+		})
+	}
+	return corners
+}
+
+// FindInflections returns the indices where the curvature changes sign
+// between two bends of at least minCurvature; the wobble of straight pixel
+// edges around zero is ignored.
+func FindInflections(curv []float64, minCurvature float64) []int {
+	n := len(curv)
+	// start from a significant bend so the ring can be walked once
+	start := -1
+	for i, k := range curv {
+		if math.Abs(k) >= minCurvature {
+			start = i
+			break
+		}
+	}
+	if start < 0 {
+		return nil
+	}
+
+	var out []int
+	sign := math.Signbit(curv[start])
+	cross := -1 // last zero crossing since the previous significant bend
+	for j := 1; j <= n; j++ {
+		i := (start + j) % n
+		prev := curv[(i-1+n)%n]
+		if math.Signbit(prev) != math.Signbit(curv[i]) {
+			cross = i
+		}
+		if math.Abs(curv[i]) < minCurvature {
+			continue
+		}
+		if s := math.Signbit(curv[i]); s != sign {
+			if cross >= 0 {
+				out = append(out, cross)
+			}
+			sign = s
+		}
+		cross = -1
+	}
+	return out
+}
+
+// AnalyzeCorners finds the corners and inflection points of every contour.
+func AnalyzeCorners(ctx context.Context, contours []Contour, opts CornerOptions) ([]ContourCorners, error) {
+	out := make([]ContourCorners, 0, len(contours))
+	for _, c := range contours {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		cc := ContourCorners{ID: c.ID, Hole: c.Hole, Corners: FindCorners(c.Points, opts)}
+		curv := Curvature(toPointsF(c.Points), opts.Sigma)
+		for _, i := range FindInflections(curv, opts.MinCurvature) {
+			cc.Inflections = append(cc.Inflections, c.Points[i])
+		}
+		out = append(out, cc)
+	}
+	return out, nil
+}
+
+// WriteCornersCSV writes one row per corner and inflection point: contour
+// id, hole flag, kind ("corner" or "inflection"), position, and for corners
+// the angle and whether they are convex.
+func WriteCornersCSV(w io.Writer, cs []ContourCorners) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "hole", "kind", "x", "y", "angle", "convex"})
+	for _, c := range cs {
+		id, hole := strconv.Itoa(c.ID), strconv.FormatBool(c.Hole)
+		for _, k := range c.Corners {
+			cw.Write([]string{id, hole, "corner", strconv.Itoa(k.Point.X), strconv.Itoa(k.Point.Y),
+				strconv.FormatFloat(k.Angle, 'f', 1, 64), strconv.FormatBool(k.Convex)})
+		}
+		for _, p := range c.Inflections {
+			cw.Write([]string{id, hole, "inflection", strconv.Itoa(p.X), strconv.Itoa(p.Y), "", ""})
+		}
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// DrawCorners marks the corners on a copy of src with red (convex) or
+// blue (concave) crosses labelled with their angle, and the inflection
+// points with small green squares.
+func DrawCorners(ctx context.Context, src image.Image, cs []ContourCorners) (image.Image, error) {
+	bounds := src.Bounds()
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, src, bounds.Min, draw.Src)
+
+	convex := color.RGBA{R: 220, A: 255}
+	concave := color.RGBA{B: 220, A: 255}
+	inflection := color.RGBA{G: 170, A: 255}
+	const arm = 4
+	var labels []TextLabel
+	for _, c := range cs {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, p := range c.Inflections {
+			draw.Draw(out, image.Rect(p.X-2, p.Y-2, p.X+3, p.Y+3).Intersect(bounds), image.NewUniform(inflection), image.Point{}, draw.Src)
+		}
+		for _, k := range c.Corners {
+			col := convex
+			if !k.Convex {
+				col = concave
+			}
+			p := k.Point
+			drawLine(out, p.Add(image.Pt(-arm, -arm)), p.Add(image.Pt(arm, arm)), col)
+			drawLine(out, p.Add(image.Pt(-arm, arm)), p.Add(image.Pt(arm, -arm)), col)
+			labels = append(labels, TextLabel{
+				At:   p.Add(image.Pt(0, -arm-labelFace.Height/2-2)),
+				Text: fmt.Sprintf("%.0f", k.Angle),
+			})
+		}
+	}
+	return DrawLabels(ctx, out, labels, convex)
+}
@@ -0,0 +1,71 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"math"
+	"testing"
+)
+
+func TestCurvature(t *testing.T) {
+	c := shapeContour(t, func(p PointF) bool { return p.Dist(PointF{100, 100}) < 50 })
+	curv := Curvature(toPointsF(c.Points), DefaultCornerOptions.Sigma)
+	var mean float64
+	for _, k := range curv {
+		mean += k
+	}
+	mean /= float64(len(curv))
+	if math.Abs(mean-1.0/50) > 0.003 {
+		t.Errorf("got mean curvature %.4f, want about %.4f", mean, 1.0/50)
+	}
+}
+
+func TestFindCorners(t *testing.T) {
+	// L shape: five convex corners and one concave
+	ell := []PointF{{40, 40}, {100, 40}, {100, 110}, {160, 110}, {160, 160}, {40, 160}}
+	c := shapeContour(t, polygonShape(ell))
+	corners := FindCorners(c.Points, DefaultCornerOptions)
+	if len(corners) != 6 {
+		t.Fatalf("got %d corners, want 6: %+v", len(corners), corners)
+	}
+	concave := 0
+	for _, k := range corners {
+		if math.Abs(k.Angle-90) > 15 {
+			t.Errorf("corner at %v: got angle %.1f, want about 90", k.Point, k.Angle)
+		}
+		if !k.Convex {
+			concave++
+			if d := k.Point.Sub(image.Pt(100, 110)); abs(d.X) > 2 || abs(d.Y) > 2 {
+				t.Errorf("got concave corner at %v, want near (100,110)", k.Point)
+			}
+		}
+	}
+	if concave != 1 {
+		t.Errorf("got %d concave corners, want 1", concave)
+	}
+}
+
+func TestAnalyzeCorners(t *testing.T) {
+	// three lobes: smooth, with a concave stretch between each pair
+	lobes := filledShape(func(p PointF) bool {
+		d := p.Sub(PointF{100, 100})
+		return d.Len() < 60+15*math.Cos(3*math.Atan2(d.Y, d.X))
+	})
+	contours, err := FindContours(context.Background(), lobes)
+	if err != nil {
+		t.Fatalf("got error while finding contours:\n%s", err.Error())
+	}
+	cs, err := AnalyzeCorners(context.Background(), contours, DefaultCornerOptions)
+	if err != nil {
+		t.Fatalf("got error while analysing corners:\n%s", err.Error())
+	}
+	if len(cs) != 1 {
+		t.Fatalf("got %d contours, want 1", len(cs))
+	}
+	if n := len(cs[0].Corners); n != 0 {
+		t.Errorf("got %d corners on a smooth outline, want 0", n)
+	}
+	if n := len(cs[0].Inflections); n != 6 {
+		t.Errorf("got %d inflections, want 6", n)
+	}
+}
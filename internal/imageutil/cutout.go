@@ -0,0 +1,95 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+)
+
+// -----------------------------------------------------------------------------
+// Masks and cut-outs
+// -----------------------------------------------------------------------------
+
+// ContourMask rasterises the filled contours into an alpha mask of bounds:
+// 255 inside the objects, 0 in the background and in their holes. The
+// border pixels themselves count as inside, so an unfeathered mask matches
+// the binary image the contours were traced from. feather > 0 softens the
+// edge with a Gaussian of that sigma, in pixels.
+func ContourMask(ctx context.Context, bounds image.Rectangle, contours []Contour, feather float64) (*image.Alpha, error) {
+	mask := image.NewAlpha(bounds)
+
+	rings := make([][]PointF, len(contours))
+	holes := make(map[int][][]PointF)
+	for i, c := range contours {
+		rings[i] = centred(c.Points)
+		if c.Hole {
+			holes[c.Parent] = append(holes[c.Parent], rings[i])
+		}
+	}
+	for i, c := range contours {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if c.Hole {
+			continue
+		}
+		object := append([][]PointF{rings[i]}, holes[c.ID]...)
+		r := ringsBounds(object, 1).Intersect(bounds)
+		draw.Draw(mask, r, fillRings(r, object, false), image.Point{}, draw.Over)
+	}
+	// the polygons run through the border pixel centres
+	for _, c := range contours {
+		for _, p := range c.Points {
+			mask.SetAlpha(p.X, p.Y, color.Alpha{A: 255})
+		}
+	}
+
+	if feather <= 0 {
+		return mask, ctx.Err()
+	}
+	w, h := bounds.Dx(), bounds.Dy()
+	plane := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			plane[y*w+x] = float64(mask.Pix[y*mask.Stride+x])
+		}
+	}
+	plane = gaussianBlur(plane, w, h, feather)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			mask.Pix[y*mask.Stride+x] = uint8(math.Round(min(max(plane[y*w+x], 0), 255)))
+		}
+	}
+	return mask, ctx.Err()
+}
+
+// MaskImage returns mask as a grey image, white inside the objects and
+// black outside, the way background-removal tools expect it.
+func MaskImage(mask *image.Alpha) *image.Gray {
+	bounds := mask.Bounds()
+	out := image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		copy(out.Pix[out.PixOffset(bounds.Min.X, y):], mask.Pix[mask.PixOffset(bounds.Min.X, y):][:bounds.Dx()])
+	}
+	return out
+}
+
+// Cutout returns src with mask as its alpha channel, so everything outside
+// the objects becomes transparent.
+func Cutout(ctx context.Context, src image.Image, mask *image.Alpha) (image.Image, error) {
+	bounds := src.Bounds()
+	out := image.NewNRGBA(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			c := color.NRGBAModel.Convert(src.At(x, y)).(color.NRGBA)
+			c.A = uint8(uint32(c.A) * uint32(mask.AlphaAt(x, y).A) / 255)
+			out.SetNRGBA(x, y, c)
+		}
+	}
+	return out, ctx.Err()
+}
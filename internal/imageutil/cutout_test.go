@@ -0,0 +1,66 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"testing"
+)
+
+func TestContourMask(t *testing.T) {
+	// ring with a dot inside its hole, and a triangle
+	mask := filledShape(func(p PointF) bool {
+		d := p.Dist(PointF{80, 80})
+		tri := p.Y < 190 && p.Y-150 > 2*math.Abs(p.X-170)
+		return (d < 60 && d > 30) || d < 12 || tri
+	})
+	contours, err := FindContours(context.Background(), mask)
+	if err != nil {
+		t.Fatalf("got error while finding contours:\n%s", err.Error())
+	}
+
+	alpha, err := ContourMask(context.Background(), mask.Bounds(), contours, 0)
+	if err != nil {
+		t.Fatalf("got error while filling contours:\n%s", err.Error())
+	}
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			want := uint8(0)
+			if mask.GrayAt(x, y).Y == 0 {
+				want = 255
+			}
+			if a := alpha.AlphaAt(x, y).A; a != want {
+				t.Fatalf("got alpha %d at (%d,%d), want %d", a, x, y, want)
+			}
+		}
+	}
+
+	soft, err := ContourMask(context.Background(), mask.Bounds(), contours, 2)
+	if err != nil {
+		t.Fatalf("got error while feathering mask:\n%s", err.Error())
+	}
+	if a := soft.AlphaAt(80, 80).A; a != 255 {
+		t.Errorf("got alpha %d in the middle of the dot, want 255", a)
+	}
+	if a := soft.AlphaAt(80+60, 80).A; a == 0 || a == 255 {
+		t.Errorf("got alpha %d on the feathered edge, want partial", a)
+	}
+
+	img := image.NewRGBA(mask.Bounds())
+	draw.Draw(img, img.Bounds(), image.NewUniform(color.RGBA{10, 200, 30, 255}), image.Point{}, draw.Src)
+	out, err := Cutout(context.Background(), img, alpha)
+	if err != nil {
+		t.Fatalf("got error while cutting out:\n%s", err.Error())
+	}
+	if a := color.NRGBAModel.Convert(out.At(80+45, 80)).(color.NRGBA).A; a != 255 {
+		t.Errorf("got alpha %d on the ring, want opaque", a)
+	}
+	if a := color.NRGBAModel.Convert(out.At(80+20, 80)).(color.NRGBA).A; a != 0 {
+		t.Errorf("got alpha %d in the hole, want transparent", a)
+	}
+	if g := MaskImage(alpha).GrayAt(80, 80).Y; g != 255 {
+		t.Errorf("got mask %d on the dot, want white", g)
+	}
+}
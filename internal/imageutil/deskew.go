@@ -0,0 +1,128 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+
+	xdraw "golang.org/x/image/draw"
+	"golang.org/x/image/math/f64"
+)
+
+// -----------------------------------------------------------------------------
+// Skew estimation
+// -----------------------------------------------------------------------------
+
+const (
+	DefaultMaxSkew = 15.0   // degrees searched on either side of horizontal
+	skewMaxSamples = 200000 // black pixels used for the projection profiles
+	skewCoarseStep = 0.5    // degrees
+	skewFineStep   = 0.05   // degrees
+)
+
+// EstimateSkew finds the angle (degrees) of the text lines of a binary page
+// image by searching for the rotation whose horizontal projection profile
+// is sharpest. Positive angles mean the lines fall to the right.
+func EstimateSkew(ctx context.Context, bin image.Image, maxAngle float64) (float64, error) {
+	bounds := bin.Bounds()
+
+	// collect the black pixels, relative to the image centre
+	cx := float64(bounds.Min.X+bounds.Max.X) / 2
+	cy := float64(bounds.Min.Y+bounds.Max.Y) / 2
+	var pts []PointF
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		if err := ctx.Err(); err != nil {
+			return 0, err
+		}
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if isBlack(bin, x, y) {
+				pts = append(pts, PointF{float64(x) - cx, float64(y) - cy})
+			}
+		}
+	}
+	if len(pts) == 0 {
+		return 0, nil
+	}
+	if step := len(pts) / skewMaxSamples; step > 1 {
+		sub := pts[:0]
+		for i := 0; i < len(pts); i += step {
+			sub = append(sub, pts[i])
+		}
+		pts = sub
+	}
+
+	diag := math.Hypot(float64(bounds.Dx()), float64(bounds.Dy()))
+	rows := make([]float64, int(diag)+3)
+	score := func(deg float64) float64 {
+		sin, cos := math.Sincos(deg * math.Pi / 180)
+		clear(rows)
+		for _, p := range pts {
+			r := int(p.Y*cos-p.X*sin+diag/2) + 1
+			rows[r]++
+		}
+		// sharp profiles have large jumps between neighbouring rows
+		var s float64
+		for i := 1; i < len(rows); i++ {
+			d := rows[i] - rows[i-1]
+			s += d * d
+		}
+		return s
+	}
+
+	search := func(from, to, step float64) (float64, error) {
+		best, bestScore := 0.0, -1.0
+		for a := from; a <= to+1e-9; a += step {
+			if err := ctx.Err(); err != nil {
+				return 0, err
+			}
+			if s := score(a); s > bestScore {
+				best, bestScore = a, s
+			}
+		}
+		return best, nil
+	}
+
+	coarse, err := search(-maxAngle, maxAngle, skewCoarseStep)
+	if err != nil {
+		return 0, err
+	}
+	return search(coarse-skewCoarseStep, coarse+skewCoarseStep, skewFineStep)
+}
+
+// -----------------------------------------------------------------------------
+// Rotation
+// -----------------------------------------------------------------------------
+
+// Rotate turns src by deg degrees clockwise around its centre, keeping the
+// image size. Uncovered areas are filled with bg.
+func Rotate(ctx context.Context, src image.Image, deg float64, bg color.Color, interp Interpolation) (image.Image, error) {
+	bounds := src.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, image.NewUniform(bg), image.Point{}, draw.Src)
+
+	sin, cos := math.Sincos(deg * math.Pi / 180)
+	cx := float64(bounds.Min.X+bounds.Max.X) / 2
+	cy := float64(bounds.Min.Y+bounds.Max.Y) / 2
+	s2d := f64.Aff3{
+		cos, -sin, cx - cos*cx + sin*cy,
+		sin, cos, cy - sin*cx - cos*cy,
+	}
+	interp.kernel().Transform(dst, s2d, src, bounds, xdraw.Over, nil)
+	return dst, ctx.Err()
+}
+
+// Deskew estimates the skew of bin (the binarized src) and rotates src to
+// straighten it. It returns the corrected image and the detected angle.
+func Deskew(ctx context.Context, src, bin image.Image, maxAngle float64) (image.Image, float64, error) {
+	angle, err := EstimateSkew(ctx, bin, maxAngle)
+	if err != nil {
+		return nil, 0, err
+	}
+	if angle == 0 {
+		return src, 0, nil
+	}
+	out, err := Rotate(ctx, src, -angle, color.White, Bilinear)
+	return out, angle, err
+}
@@ -0,0 +1,70 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// skewedLines returns a white 300x200 page with dashed "text lines" tilted
+// by deg degrees (falling to the right for positive angles).
+func skewedLines(deg float64) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 300, 200))
+	tan := math.Tan(deg * math.Pi / 180)
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 300; x++ {
+			v := uint8(255)
+			// line centre at this column, measured from the page centre
+			ly := float64(y) - 100 - (float64(x)-150)*tan
+			if x > 30 && x < 270 && (x/12)%4 != 3 {
+				if r := math.Mod(ly+200, 20); r < 4 {
+					v = 0
+				}
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+func TestEstimateSkew(t *testing.T) {
+	ctx := context.Background()
+	for _, want := range []float64{-6, 0, 3.5} {
+		got, err := EstimateSkew(ctx, skewedLines(want), DefaultMaxSkew)
+		if err != nil {
+			t.Fatalf("got error while estimating skew:\n%s", err.Error())
+		}
+		if math.Abs(got-want) > 0.3 {
+			t.Fatalf("got skew %.2f°, want %.2f°", got, want)
+		}
+	}
+}
+
+func TestDeskew(t *testing.T) {
+	ctx := context.Background()
+	page := skewedLines(4)
+	out, angle, err := Deskew(ctx, page, page, DefaultMaxSkew)
+	if err != nil {
+		t.Fatalf("got error while deskewing:\n%s", err.Error())
+	}
+	if math.Abs(angle-4) > 0.3 {
+		t.Fatalf("got skew %.2f°, want 4°", angle)
+	}
+	if out.Bounds() != page.Bounds() {
+		t.Fatalf("got bounds %v, want %v", out.Bounds(), page.Bounds())
+	}
+
+	bin, err := OtsuBinarize(ctx, out)
+	if err != nil {
+		t.Fatalf("got error while binarizing:\n%s", err.Error())
+	}
+	rest, err := EstimateSkew(ctx, bin, DefaultMaxSkew)
+	if err != nil {
+		t.Fatalf("got error while estimating skew:\n%s", err.Error())
+	}
+	if math.Abs(rest) > 0.3 {
+		t.Fatalf("straightened page still skewed by %.2f°", rest)
+	}
+}
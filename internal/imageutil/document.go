@@ -0,0 +1,292 @@
+package imageutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strings"
+
+	xdraw "golang.org/x/image/draw"
+)
+
+// -----------------------------------------------------------------------------
+// Document border detection
+// -----------------------------------------------------------------------------
+
+// Quad - document corners in order top-left, top-right, bottom-right, bottom-left.
+type Quad [4]PointF
+
+// ErrNoDocument - no contour that could be a document was found.
+var ErrNoDocument = errors.New("no document border found")
+
+// Size returns the output size of the flattened quad: the longer of each
+// pair of opposite sides.
+func (q Quad) Size() (w, h int) {
+	w = int(math.Round(math.Max(q[0].Dist(q[1]), q[3].Dist(q[2]))))
+	h = int(math.Round(math.Max(q[0].Dist(q[3]), q[1].Dist(q[2]))))
+	return max(w, 1), max(h, 1)
+}
+
+// orderQuad sorts four corners into top-left, top-right, bottom-right,
+// bottom-left by their coordinate sums and differences.
+func orderQuad(pts []PointF) Quad {
+	var q Quad
+	q[0], q[1], q[2], q[3] = pts[0], pts[0], pts[0], pts[0]
+	for _, p := range pts {
+		if p.X+p.Y < q[0].X+q[0].Y {
+			q[0] = p
+		}
+		if p.X-p.Y > q[1].X-q[1].Y {
+			q[1] = p
+		}
+		if p.X+p.Y > q[2].X+q[2].Y {
+			q[2] = p
+		}
+		if p.X-p.Y < q[3].X-q[3].Y {
+			q[3] = p
+		}
+	}
+	return q
+}
+
+// DetectDocument finds the largest quadrilateral contour in src - usually
+// the border of a page - and returns its refined corners.
+func DetectDocument(ctx context.Context, src image.Image) (Quad, error) {
+	edges, err := Canny(ctx, src, DefaultCannyOptions)
+	if err != nil {
+		return Quad{}, err
+	}
+	contours, err := FindContours(ctx, edges)
+	if err != nil {
+		return Quad{}, err
+	}
+
+	var (
+		best, largest         []PointF // best quad, largest hull
+		bestArea, largestArea float64
+		bestPts               []PointF // contour points of the best quad
+	)
+	for _, c := range contours {
+		if c.Hole || len(c.Points) < 4 {
+			continue
+		}
+		pts := toPointsF(c.Points)
+		hull := ConvexHull(pts)
+		area := PolygonArea(hull)
+		if area > largestArea {
+			largest, largestArea = hull, area
+		}
+		approx := ApproxPolygon(hull, 0.02*Perimeter(hull))
+		if len(approx) == 4 && area > bestArea {
+			best, bestArea, bestPts = approx, area, pts
+		}
+	}
+	if largest == nil {
+		return Quad{}, ErrNoDocument
+	}
+	if best == nil {
+		// no clean quadrilateral: take the extreme points of the largest hull
+		return orderQuad(largest), nil
+	}
+	return refineQuad(orderQuad(best), bestPts), nil
+}
+
+// refineQuad fits a line to the contour points along every side of q and
+// moves the corners to the intersections of neighbouring lines.
+func refineQuad(q Quad, pts []PointF) Quad {
+	var lineP, lineD [4]PointF
+	for s := 0; s < 4; s++ {
+		a, b := q[s], q[(s+1)%4]
+		side := a.Dist(b)
+		tol := math.Max(2, 0.02*side)
+		var near []PointF
+		for _, p := range pts {
+			// skip the rounded ends near the corners
+			t := p.Sub(a).Dot(b.Sub(a)) / (side * side)
+			if t > 0.1 && t < 0.9 && segmentDistance(p, a, b) <= tol {
+				near = append(near, p)
+			}
+		}
+		if len(near) < 2 {
+			return q
+		}
+		lineP[s], lineD[s] = fitLine(near)
+	}
+
+	var out Quad
+	for c := 0; c < 4; c++ {
+		prev := (c + 3) % 4 // side ending at corner c
+		p, ok := intersectLines(lineP[prev], lineD[prev], lineP[c], lineD[c])
+		if !ok || p.Dist(q[c]) > 0.1*q[c].Dist(q[(c+2)%4]) {
+			return q
+		}
+		out[c] = p
+	}
+	return out
+}
+
+// -----------------------------------------------------------------------------
+// Perspective correction
+// -----------------------------------------------------------------------------
+
+// Homography - 3x3 projective transform in row-major order.
+type Homography [9]float64
+
+// Apply maps p through the homography.
+func (h Homography) Apply(p PointF) PointF {
+	w := h[6]*p.X + h[7]*p.Y + h[8]
+	return PointF{
+		(h[0]*p.X + h[1]*p.Y + h[2]) / w,
+		(h[3]*p.X + h[4]*p.Y + h[5]) / w,
+	}
+}
+
+// NewHomography returns the transform mapping the four points from onto to.
+func NewHomography(from, to [4]PointF) (Homography, error) {
+	// 8 equations for h0..h7 with h8 = 1
+	var a [8][9]float64
+	for i := 0; i < 4; i++ {
+		x, y, u, v := from[i].X, from[i].Y, to[i].X, to[i].Y
+		a[2*i] = [9]float64{x, y, 1, 0, 0, 0, -u * x, -u * y, u}
+		a[2*i+1] = [9]float64{0, 0, 0, x, y, 1, -v * x, -v * y, v}
+	}
+
+	// Gaussian elimination with partial pivoting
+	for col := 0; col < 8; col++ {
+		piv := col
+		for r := col + 1; r < 8; r++ {
+			if math.Abs(a[r][col]) > math.Abs(a[piv][col]) {
+				piv = r
+			}
+		}
+		if math.Abs(a[piv][col]) < 1e-12 {
+			return Homography{}, fmt.Errorf("degenerate corner points: three of them are collinear")
+		}
+		a[col], a[piv] = a[piv], a[col]
+		for r := 0; r < 8; r++ {
+			if r == col {
+				continue
+			}
+			k := a[r][col] / a[col][col]
+			for c := col; c < 9; c++ {
+				a[r][c] -= k * a[col][c]
+			}
+		}
+	}
+
+	var h Homography
+	for i := 0; i < 8; i++ {
+		h[i] = a[i][8] / a[i][i]
+	}
+	h[8] = 1
+	return h, nil
+}
+
+// Interpolation - resampling kernel used when warping.
+type Interpolation int
+
+const (
+	Bilinear Interpolation = iota
+	Bicubic
+)
+
+// ParseInterpolation converts "bilinear" or "bicubic" to an Interpolation.
+func ParseInterpolation(s string) (Interpolation, error) {
+	switch strings.ToLower(s) {
+	case "bilinear", "linear":
+		return Bilinear, nil
+	case "bicubic", "cubic":
+		return Bicubic, nil
+	}
+	return 0, fmt.Errorf("unknown interpolation %q (want bilinear or bicubic)", s)
+}
+
+func (i Interpolation) String() string {
+	if i == Bicubic {
+		return "bicubic"
+	}
+	return "bilinear"
+}
+
+func (i Interpolation) kernel() *xdraw.Kernel {
+	if i == Bicubic {
+		return xdraw.CatmullRom
+	}
+	return xdraw.BiLinear
+}
+
+// FlattenDocument warps the quad q of src to an upright rectangle.
+func FlattenDocument(ctx context.Context, src image.Image, q Quad, interp Interpolation) (image.Image, error) {
+	w, h := q.Size()
+	return WarpPerspective(ctx, src, q, w, h, interp)
+}
+
+// WarpPerspective maps the quad q of src onto a w x h image.
+func WarpPerspective(ctx context.Context, src image.Image, q Quad, w, h int, interp Interpolation) (image.Image, error) {
+	dstCorners := [4]PointF{{0, 0}, {float64(w), 0}, {float64(w), float64(h)}, {0, float64(h)}}
+	// inverse mapping: destination pixel -> source position
+	hm, err := NewHomography(dstCorners, q)
+	if err != nil {
+		return nil, err
+	}
+
+	sb := src.Bounds()
+	rgba := image.NewRGBA(sb)
+	draw.Draw(rgba, sb, src, sb.Min, draw.Src)
+
+	k := interp.kernel()
+	support := k.Support
+	out := image.NewRGBA(image.Rect(0, 0, w, h))
+
+	for y := 0; y < h; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for x := 0; x < w; x++ {
+			sp := hm.Apply(PointF{float64(x) + 0.5, float64(y) + 0.5})
+			// pixel centres sit at +0.5
+			cx, cy := sp.X-0.5, sp.Y-0.5
+			x0, x1 := int(math.Ceil(cx-support)), int(math.Floor(cx+support))
+			y0, y1 := int(math.Ceil(cy-support)), int(math.Floor(cy+support))
+
+			var r, g, b, a, wsum float64
+			for sy := y0; sy <= y1; sy++ {
+				wy := k.At(math.Abs(cy - float64(sy)))
+				if wy == 0 {
+					continue
+				}
+				py := min(max(sy, sb.Min.Y), sb.Max.Y-1)
+				for sx := x0; sx <= x1; sx++ {
+					wx := k.At(math.Abs(cx - float64(sx)))
+					if wx == 0 {
+						continue
+					}
+					px := min(max(sx, sb.Min.X), sb.Max.X-1)
+					c := rgba.RGBAAt(px, py)
+					wt := wx * wy
+					r += wt * float64(c.R)
+					g += wt * float64(c.G)
+					b += wt * float64(c.B)
+					a += wt * float64(c.A)
+					wsum += wt
+				}
+			}
+			if wsum != 0 {
+				r, g, b, a = r/wsum, g/wsum, b/wsum, a/wsum
+			}
+			ca := clamp8(a)
+			out.SetRGBA(x, y, color.RGBA{
+				R: min(clamp8(r), ca), G: min(clamp8(g), ca), B: min(clamp8(b), ca), A: ca,
+			})
+		}
+	}
+	return out, ctx.Err()
+}
+
+func clamp8(v float64) uint8 {
+	return uint8(math.Max(0, math.Min(255, math.Round(v))))
+}
@@ -0,0 +1,77 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// paperPhoto returns a dark 200x160 image with a bright quadrilateral page.
+func paperPhoto(corners [4]PointF) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 200, 160))
+	poly := corners[:]
+	for y := 0; y < 160; y++ {
+		for x := 0; x < 200; x++ {
+			v := uint8(40)
+			if insidePolygon(PointF{float64(x) + 0.5, float64(y) + 0.5}, poly) {
+				v = 230
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+// insidePolygon is an even-odd point-in-polygon test.
+func insidePolygon(p PointF, poly []PointF) bool {
+	in := false
+	for i, j := 0, len(poly)-1; i < len(poly); j, i = i, i+1 {
+		a, b := poly[i], poly[j]
+		if (a.Y > p.Y) != (b.Y > p.Y) && p.X < (b.X-a.X)*(p.Y-a.Y)/(b.Y-a.Y)+a.X {
+			in = !in
+		}
+	}
+	return in
+}
+
+func TestDetectDocument(t *testing.T) {
+	want := Quad{{40, 20}, {170, 35}, {160, 140}, {25, 125}}
+	q, err := DetectDocument(context.Background(), paperPhoto(want))
+	if err != nil {
+		t.Fatalf("got error while detecting document:\n%s", err.Error())
+	}
+	for i := range want {
+		if d := q[i].Dist(want[i]); d > 3 {
+			t.Fatalf("corner %d: got %v, want %v (off by %.1f px)", i, q[i], want[i], d)
+		}
+	}
+}
+
+func TestFlattenDocument(t *testing.T) {
+	q := Quad{{40, 20}, {170, 35}, {160, 140}, {25, 125}}
+	hm, err := NewHomography([4]PointF(q), [4]PointF{{0, 0}, {100, 0}, {100, 80}, {0, 80}})
+	if err != nil {
+		t.Fatalf("got error while computing homography:\n%s", err.Error())
+	}
+	if p := hm.Apply(q[2]); p.Dist(PointF{100, 80}) > 1e-6 {
+		t.Fatalf("homography maps %v to %v, want (100,80)", q[2], p)
+	}
+
+	for _, interp := range []Interpolation{Bilinear, Bicubic} {
+		page, err := FlattenDocument(context.Background(), paperPhoto(q), q, interp)
+		if err != nil {
+			t.Fatalf("got error while flattening document:\n%s", err.Error())
+		}
+		w, h := q.Size()
+		if page.Bounds() != image.Rect(0, 0, w, h) {
+			t.Fatalf("got bounds %v, want %dx%d", page.Bounds(), w, h)
+		}
+		// the page interior is bright everywhere
+		for _, p := range []image.Point{{5, 5}, {w / 2, h / 2}, {w - 6, h - 6}} {
+			if y := color.GrayModel.Convert(page.At(p.X, p.Y)).(color.Gray).Y; y < 200 {
+				t.Fatalf("%s: pixel %v is %d, want page white", interp, p, y)
+			}
+		}
+	}
+}
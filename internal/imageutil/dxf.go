@@ -0,0 +1,255 @@
+package imageutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// DXF export
+// -----------------------------------------------------------------------------
+
+// DXFUnits - drawing units stored in the $INSUNITS header variable.
+type DXFUnits int
+
+const (
+	DXFUnitless   DXFUnits = 0
+	DXFInches     DXFUnits = 1
+	DXFMillimeter DXFUnits = 4
+	DXFCentimeter DXFUnits = 5
+	DXFMeter      DXFUnits = 6
+)
+
+// ParseDXFUnits converts "mm", "cm", "m", "in" or "none".
+func ParseDXFUnits(s string) (DXFUnits, error) {
+	switch strings.ToLower(s) {
+	case "mm":
+		return DXFMillimeter, nil
+	case "cm":
+		return DXFCentimeter, nil
+	case "m":
+		return DXFMeter, nil
+	case "in", "inch":
+		return DXFInches, nil
+	case "none", "":
+		return DXFUnitless, nil
+	}
+	return DXFUnitless, fmt.Errorf("unknown DXF units %q (want mm, cm, m, in or none)", s)
+}
+
+// DXF layer names of outer contours and holes.
+const (
+	DXFOuterLayer = "OUTER"
+	DXFHoleLayer  = "HOLES"
+)
+
+// DXFOptions - settings for WriteDXF.
+type DXFOptions struct {
+	Scale    float64  // drawing units per pixel
+	Units    DXFUnits // units written to the header
+	Simplify float64  // Douglas-Peucker tolerance in pixels, 0 keeps every pixel
+}
+
+// DefaultDXFOptions - one millimetre per pixel, every pixel kept.
+var DefaultDXFOptions = DXFOptions{Scale: 1, Units: DXFMillimeter}
+
+// WriteDXF writes every contour as a closed LWPOLYLINE to an ASCII DXF
+// R2000 file: outer contours on layer OUTER, holes on layer HOLES. The y
+// axis is flipped so the drawing is upright in CAD, with the origin at the
+// bottom left of bounds. Besides the entities the file carries the tables,
+// blocks and objects an R2000 reader expects, each with its handle and
+// owner.
+func WriteDXF(ctx context.Context, w io.Writer, bounds image.Rectangle, contours []Contour, opts DXFOptions) error {
+	// everything after the header is buffered, as $HANDSEED has to give
+	// the first handle left unused
+	var body bytes.Buffer
+	pair := func(code int, value string) {
+		fmt.Fprintf(&body, "%3d\n%s\n", code, value)
+	}
+	num := func(v float64) string { return strconv.FormatFloat(v, 'f', -1, 64) }
+	var last uint64
+	handle := func() string {
+		last++
+		return strings.ToUpper(strconv.FormatUint(last, 16))
+	}
+	// object starts an object of type typ owned by owner and returns its handle
+	object := func(typ, owner string, subclasses ...string) string {
+		h := handle()
+		pair(0, typ)
+		pair(5, h)
+		pair(330, owner)
+		for _, sc := range subclasses {
+			pair(100, sc)
+		}
+		return h
+	}
+	// table writes a symbol table of n records, written by records
+	table := func(name string, n int, records func(owner string)) {
+		h := handle()
+		pair(0, "TABLE")
+		pair(2, name)
+		pair(5, h)
+		pair(330, "0")
+		pair(100, "AcDbSymbolTable")
+		pair(70, strconv.Itoa(n))
+		if records != nil {
+			records(h)
+		}
+		pair(0, "ENDTAB")
+	}
+	none := func(string) {}
+
+	pair(0, "SECTION")
+	pair(2, "TABLES")
+	table("VPORT", 0, none)
+	table("LTYPE", 3, func(owner string) {
+		for _, name := range []string{"ByBlock", "ByLayer", "Continuous"} {
+			object("LTYPE", owner, "AcDbSymbolTableRecord", "AcDbLinetypeTableRecord")
+			pair(2, name)
+			pair(70, "0")
+			pair(3, "")
+			pair(72, "65")
+			pair(73, "0")
+			pair(40, "0.0")
+		}
+	})
+	table("LAYER", 3, func(owner string) {
+		for _, l := range []struct {
+			name  string
+			color int
+		}{{"0", 7}, {DXFOuterLayer, 7}, {DXFHoleLayer, 5}} {
+			object("LAYER", owner, "AcDbSymbolTableRecord", "AcDbLayerTableRecord")
+			pair(2, l.name)
+			pair(70, "0")
+			pair(62, strconv.Itoa(l.color))
+			pair(6, "Continuous")
+		}
+	})
+	table("STYLE", 1, func(owner string) {
+		object("STYLE", owner, "AcDbSymbolTableRecord", "AcDbTextStyleTableRecord")
+		pair(2, "Standard")
+		pair(70, "0")
+		pair(40, "0.0")
+		pair(41, "1.0")
+		pair(50, "0.0")
+		pair(71, "0")
+		pair(42, "2.5")
+		pair(3, "txt")
+		pair(4, "")
+	})
+	table("VIEW", 0, none)
+	table("UCS", 0, none)
+	table("APPID", 1, func(owner string) {
+		object("APPID", owner, "AcDbSymbolTableRecord", "AcDbRegAppTableRecord")
+		pair(2, "ACAD")
+		pair(70, "0")
+	})
+	table("DIMSTYLE", 0, func(string) {
+		pair(100, "AcDbDimStyleTable")
+		pair(71, "0")
+	})
+	var modelSpace, paperSpace string
+	table("BLOCK_RECORD", 2, func(owner string) {
+		modelSpace = object("BLOCK_RECORD", owner, "AcDbSymbolTableRecord", "AcDbBlockTableRecord")
+		pair(2, "*Model_Space")
+		paperSpace = object("BLOCK_RECORD", owner, "AcDbSymbolTableRecord", "AcDbBlockTableRecord")
+		pair(2, "*Paper_Space")
+	})
+	pair(0, "ENDSEC")
+
+	pair(0, "SECTION")
+	pair(2, "BLOCKS")
+	for _, b := range []struct{ name, owner string }{{"*Model_Space", modelSpace}, {"*Paper_Space", paperSpace}} {
+		object("BLOCK", b.owner, "AcDbEntity")
+		pair(8, "0")
+		pair(100, "AcDbBlockBegin")
+		pair(2, b.name)
+		pair(70, "0")
+		pair(10, "0.0")
+		pair(20, "0.0")
+		pair(30, "0.0")
+		pair(3, b.name)
+		pair(1, "")
+		object("ENDBLK", b.owner, "AcDbEntity")
+		pair(8, "0")
+		pair(100, "AcDbBlockEnd")
+	}
+	pair(0, "ENDSEC")
+
+	pair(0, "SECTION")
+	pair(2, "ENTITIES")
+	for _, c := range contours {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		pts := toPointsF(c.Points)
+		if opts.Simplify > 0 {
+			pts = ApproxPolygon(pts, opts.Simplify)
+		}
+		if len(pts) < 2 {
+			continue
+		}
+		layer := DXFOuterLayer
+		if c.Hole {
+			layer = DXFHoleLayer
+		}
+		object("LWPOLYLINE", modelSpace, "AcDbEntity")
+		pair(8, layer)
+		pair(100, "AcDbPolyline")
+		pair(90, strconv.Itoa(len(pts)))
+		pair(70, "1") // closed
+		for _, p := range pts {
+			// pixel centres, y up
+			pair(10, num((p.X+0.5-float64(bounds.Min.X))*opts.Scale))
+			pair(20, num((float64(bounds.Max.Y)-p.Y-0.5)*opts.Scale))
+		}
+	}
+	pair(0, "ENDSEC")
+
+	// the root dictionary with the group dictionary every drawing has
+	pair(0, "SECTION")
+	pair(2, "OBJECTS")
+	root, groups := handle(), handle()
+	pair(0, "DICTIONARY")
+	pair(5, root)
+	pair(330, "0")
+	pair(100, "AcDbDictionary")
+	pair(281, "1")
+	pair(3, "ACAD_GROUP")
+	pair(350, groups)
+	pair(0, "DICTIONARY")
+	pair(5, groups)
+	pair(330, root)
+	pair(100, "AcDbDictionary")
+	pair(281, "1")
+	pair(0, "ENDSEC")
+	pair(0, "EOF")
+
+	bw := bufio.NewWriter(w)
+	header := func(code int, value string) {
+		fmt.Fprintf(bw, "%3d\n%s\n", code, value)
+	}
+	header(0, "SECTION")
+	header(2, "HEADER")
+	header(9, "$ACADVER")
+	header(1, "AC1015")
+	header(9, "$HANDSEED")
+	header(5, strings.ToUpper(strconv.FormatUint(last+1, 16)))
+	header(9, "$INSUNITS")
+	header(70, strconv.Itoa(int(opts.Units)))
+	header(9, "$MEASUREMENT")
+	if opts.Units == DXFInches {
+		header(70, "0")
+	} else {
+		header(70, "1")
+	}
+	header(0, "ENDSEC")
+	body.WriteTo(bw)
+	return bw.Flush()
+}
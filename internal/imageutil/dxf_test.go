@@ -0,0 +1,180 @@
+package imageutil
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"strconv"
+	"strings"
+	"testing"
+)
+
+func TestWriteDXF(t *testing.T) {
+	// 10x10 frame with a 4x4 hole in a 20x20 image
+	mask := image.NewGray(image.Rect(0, 0, 20, 20))
+	for y := range 20 {
+		for x := range 20 {
+			v := uint8(255)
+			if x >= 5 && x < 15 && y >= 5 && y < 15 && !(x >= 8 && x < 12 && y >= 8 && y < 12) {
+				v = 0
+			}
+			mask.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	contours, err := FindContours(context.Background(), mask)
+	if err != nil {
+		t.Fatalf("got error while finding contours:\n%s", err.Error())
+	}
+
+	var buf bytes.Buffer
+	opts := DXFOptions{Scale: 0.5, Units: DXFMillimeter, Simplify: 0.5}
+	if err := WriteDXF(context.Background(), &buf, mask.Bounds(), contours, opts); err != nil {
+		t.Fatalf("got error while writing DXF:\n%s", err.Error())
+	}
+
+	objs := dxfObjects(t, buf.String())
+
+	// outer border pixels 5..14: centres 5.5..14.5, y flipped from 20, half size
+	want := map[string]bool{"2.75": true, "7.25": true}
+	var modelSpace string
+	handles := map[uint64]bool{}
+	var seed uint64
+	lines := map[string]int{}
+	for i, o := range objs {
+		// the header's group 5 is $HANDSEED, not a handle
+		if h := o.value(5); h != "" && o.typ != "SECTION" {
+			n, err := strconv.ParseUint(h, 16, 64)
+			if err != nil || handles[n] {
+				t.Fatalf("object %d (%s): bad or repeated handle %q", i, o.typ, h)
+			}
+			handles[n] = true
+		}
+		switch o.typ {
+		case "SECTION":
+			if o.value(2) == "HEADER" {
+				seed, err = strconv.ParseUint(o.after(9, "$HANDSEED", 5), 16, 64)
+				if err != nil {
+					t.Fatalf("got error while reading $HANDSEED:\n%s", err.Error())
+				}
+				if o.after(9, "$ACADVER", 1) != "AC1015" || o.after(9, "$INSUNITS", 70) != "4" {
+					t.Errorf("got header %v, want R2000 in millimetres", o.pairs)
+				}
+			}
+		case "BLOCK_RECORD":
+			if o.value(2) == "*Model_Space" {
+				modelSpace = o.value(5)
+			}
+		case "LWPOLYLINE":
+			if o.value(330) != modelSpace || modelSpace == "" {
+				t.Errorf("polyline owned by %q, want model space %q", o.value(330), modelSpace)
+			}
+			if sc := o.values(100); len(sc) != 2 || sc[0] != "AcDbEntity" || sc[1] != "AcDbPolyline" {
+				t.Errorf("got subclass markers %v, want AcDbEntity then AcDbPolyline", sc)
+			}
+			layer := o.value(8)
+			lines[layer]++
+			if layer != DXFOuterLayer {
+				continue
+			}
+			pts := append(o.values(10), o.values(20)...)
+			if len(pts) != 8 || o.value(90) != "4" || o.value(70) != "1" {
+				t.Fatalf("got outer polyline %v, want 4 closed corners", o.pairs)
+			}
+			for _, v := range pts {
+				if !want[v] {
+					t.Errorf("unexpected outer coordinate %s in %v", v, pts)
+				}
+			}
+		}
+	}
+	if lines[DXFOuterLayer] != 1 || lines[DXFHoleLayer] != 1 {
+		t.Errorf("got polylines per layer %v, want one on each", lines)
+	}
+	for h := range handles {
+		if h >= seed {
+			t.Errorf("handle %X not below $HANDSEED %X", h, seed)
+		}
+	}
+
+	// sections in R2000 order, with the group dictionary under the root
+	var sections []string
+	for i, o := range objs {
+		if o.typ == "SECTION" {
+			sections = append(sections, o.value(2))
+		}
+		if o.typ == "DICTIONARY" && o.after(3, "ACAD_GROUP", 350) != "" {
+			if next := objs[i+1]; next.typ != "DICTIONARY" || next.value(330) != o.value(5) {
+				t.Errorf("group dictionary %v not owned by the root %s", next.pairs, o.value(5))
+			}
+		}
+	}
+	if got := strings.Join(sections, " "); got != "HEADER TABLES BLOCKS ENTITIES OBJECTS" {
+		t.Errorf("got sections %s", got)
+	}
+	if last := objs[len(objs)-1]; last.typ != "EOF" {
+		t.Errorf("got last object %s, want EOF", last.typ)
+	}
+}
+
+// dxfObject - one object of a DXF file: its type and the group pairs up
+// to the next code 0.
+type dxfObject struct {
+	typ   string
+	pairs [][2]string
+}
+
+// values returns the values of every pair with code.
+func (o dxfObject) values(code int) []string {
+	var vs []string
+	for _, p := range o.pairs {
+		if p[0] == strconv.Itoa(code) {
+			vs = append(vs, p[1])
+		}
+	}
+	return vs
+}
+
+// value returns the first value with code, or "".
+func (o dxfObject) value(code int) string {
+	if vs := o.values(code); len(vs) > 0 {
+		return vs[0]
+	}
+	return ""
+}
+
+// after returns the value with code following the pair key/name, or "".
+func (o dxfObject) after(key int, name string, code int) string {
+	for i, p := range o.pairs {
+		if p[0] == strconv.Itoa(key) && p[1] == name && i+1 < len(o.pairs) && o.pairs[i+1][0] == strconv.Itoa(code) {
+			return o.pairs[i+1][1]
+		}
+	}
+	return ""
+}
+
+// dxfObjects splits an ASCII DXF file into its objects.
+func dxfObjects(t *testing.T, s string) []dxfObject {
+	t.Helper()
+	lines := strings.Split(strings.TrimSuffix(s, "\n"), "\n")
+	if len(lines)%2 != 0 {
+		t.Fatalf("got %d lines, want code/value pairs", len(lines))
+	}
+	var objs []dxfObject
+	for i := 0; i < len(lines); i += 2 {
+		code, value := strings.TrimSpace(lines[i]), lines[i+1]
+		if _, err := strconv.Atoi(code); err != nil {
+			t.Fatalf("line %d: got group code %q", i+1, lines[i])
+		}
+		if code == "0" {
+			objs = append(objs, dxfObject{typ: value})
+			continue
+		}
+		if len(objs) == 0 {
+			t.Fatalf("line %d: group code %s outside an object", i+1, code)
+		}
+		o := &objs[len(objs)-1]
+		o.pairs = append(o.pairs, [2]string{code, value})
+	}
+	return objs
+}
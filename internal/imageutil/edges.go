@@ -0,0 +1,311 @@
+package imageutil
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+)
+
+// -----------------------------------------------------------------------------
+// Gradient operators
+// -----------------------------------------------------------------------------
+
+// EdgeOperator - 3x3 derivative kernel used to compute image gradients.
+type EdgeOperator int
+
+const (
+	Sobel EdgeOperator = iota
+	Scharr
+)
+
+// kernel weights of the smoothing direction: [a b a]
+func (op EdgeOperator) weights() (a, b float64) {
+	if op == Scharr {
+		return 3, 10
+	}
+	return 1, 2
+}
+
+// gradient - per-pixel gradient magnitude and direction of a luminance plane.
+type gradient struct {
+	w, h int
+	mag  []float64
+	dir  []float64 // radians
+	max  float64   // largest magnitude
+}
+
+// lumaPlane returns the luminance of src as a row-major float slice.
+func lumaPlane(ctx context.Context, src image.Image) ([]float64, error) {
+	bounds := src.Bounds()
+	out := make([]float64, 0, bounds.Dx()*bounds.Dy())
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			out = append(out, float64(color.GrayModel.Convert(src.At(x, y)).(color.Gray).Y))
+		}
+	}
+	return out, nil
+}
+
+// computeGradient applies op to a w x h plane, clamping at the borders.
+func computeGradient(ctx context.Context, plane []float64, w, h int, op EdgeOperator) (*gradient, error) {
+	a, b := op.weights()
+	at := func(x, y int) float64 {
+		x = min(max(x, 0), w-1)
+		y = min(max(y, 0), h-1)
+		return plane[y*w+x]
+	}
+
+	g := &gradient{w: w, h: h, mag: make([]float64, w*h), dir: make([]float64, w*h)}
+	for y := 0; y < h; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for x := 0; x < w; x++ {
+			gx := a*(at(x+1, y-1)-at(x-1, y-1)) +
+				b*(at(x+1, y)-at(x-1, y)) +
+				a*(at(x+1, y+1)-at(x-1, y+1))
+			gy := a*(at(x-1, y+1)-at(x-1, y-1)) +
+				b*(at(x, y+1)-at(x, y-1)) +
+				a*(at(x+1, y+1)-at(x+1, y-1))
+			i := y*w + x
+			g.mag[i] = math.Hypot(gx, gy)
+			g.dir[i] = math.Atan2(gy, gx)
+			g.max = math.Max(g.max, g.mag[i])
+		}
+	}
+	return g, nil
+}
+
+// normalized returns the magnitude of pixel i scaled to [0, 255].
+func (g *gradient) normalized(i int) float64 {
+	if g.max == 0 {
+		return 0
+	}
+	return g.mag[i] * 255 / g.max
+}
+
+// otsuLevel returns Otsu's threshold of the normalized magnitudes.
+func (g *gradient) otsuLevel(ctx context.Context, onlyNonZero bool) (float64, error) {
+	histogram := make([]int, 256)
+	total := 0
+	for i := range g.mag {
+		v := uint8(g.normalized(i))
+		if onlyNonZero && v == 0 {
+			continue
+		}
+		histogram[v]++
+		total++
+	}
+	t, err := otsuThreshold(ctx, histogram, total)
+	return float64(t), err
+}
+
+// GradientMagnitude returns the gradient magnitude of src as a grayscale
+// image scaled so that the strongest edge is white.
+func GradientMagnitude(ctx context.Context, src image.Image, op EdgeOperator) (image.Image, error) {
+	bounds := src.Bounds()
+	plane, err := lumaPlane(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	g, err := computeGradient(ctx, plane, bounds.Dx(), bounds.Dy(), op)
+	if err != nil {
+		return nil, err
+	}
+	out := image.NewGray(bounds)
+	for i := range g.mag {
+		out.Pix[(i/g.w)*out.Stride+i%g.w] = uint8(g.normalized(i))
+	}
+	return out, ctx.Err()
+}
+
+// gradientEdgeLevel returns the gradient of src under op and the Otsu
+// threshold GradientEdges applies to its normalized magnitudes.
+func gradientEdgeLevel(ctx context.Context, src image.Image, op EdgeOperator) (*gradient, float64, error) {
+	bounds := src.Bounds()
+	plane, err := lumaPlane(ctx, src)
+	if err != nil {
+		return nil, 0, err
+	}
+	g, err := computeGradient(ctx, plane, bounds.Dx(), bounds.Dy(), op)
+	if err != nil {
+		return nil, 0, err
+	}
+	level, err := g.otsuLevel(ctx, false)
+	if err != nil {
+		return nil, 0, err
+	}
+	return g, level, nil
+}
+
+// GradientEdges thresholds the gradient magnitude of src with Otsu's method.
+// Edge pixels are black, so the result can be fed straight into the scanner.
+func GradientEdges(ctx context.Context, src image.Image, op EdgeOperator) (image.Image, error) {
+	bounds := src.Bounds()
+	g, level, err := gradientEdgeLevel(ctx, src, op)
+	if err != nil {
+		return nil, err
+	}
+	return maskWhere(ctx, bounds, func(x, y int) bool {
+		i := (y-bounds.Min.Y)*g.w + (x - bounds.Min.X)
+		return g.max > 0 && g.normalized(i) > level
+	})
+}
+
+// -----------------------------------------------------------------------------
+// Canny
+// -----------------------------------------------------------------------------
+
+// CannyOptions - parameters for Canny. Thresholds are on the gradient
+// magnitude scaled to [0, 255]; zero High derives both from Otsu's method.
+type CannyOptions struct {
+	Sigma     float64 // Gaussian smoothing, 0 - no smoothing
+	Low, High float64 // hysteresis thresholds
+	Operator  EdgeOperator
+}
+
+// DefaultCannyOptions - Gaussian sigma 1.4 and automatic thresholds.
+var DefaultCannyOptions = CannyOptions{Sigma: 1.4}
+
+// Canny runs the Canny edge detector: Gaussian smoothing, gradient,
+// non-maximum suppression and hysteresis. Edge pixels are black.
+func Canny(ctx context.Context, src image.Image, opts CannyOptions) (image.Image, error) {
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+
+	// Step 1: smooth the luminance.
+	plane, err := lumaPlane(ctx, src)
+	if err != nil {
+		return nil, err
+	}
+	if opts.Sigma > 0 {
+		plane = gaussianBlur(plane, w, h, opts.Sigma)
+	}
+
+	// Step 2: gradient.
+	g, err := computeGradient(ctx, plane, w, h, opts.Operator)
+	if err != nil {
+		return nil, err
+	}
+
+	// Step 3: thresholds.
+	low, high := opts.Low, opts.High
+	if high <= 0 {
+		if high, err = g.otsuLevel(ctx, true); err != nil {
+			return nil, err
+		}
+		low = high / 2
+	}
+	if low > high {
+		return nil, fmt.Errorf("canny low threshold %.1f is above high threshold %.1f", low, high)
+	}
+
+	// Step 4: non-maximum suppression.
+	thin := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for x := 0; x < w; x++ {
+			i := y*w + x
+			m := g.normalized(i)
+			if m == 0 {
+				continue
+			}
+			// quantize the direction to one of four neighbour axes
+			angle := math.Mod(g.dir[i]*180/math.Pi+180, 180)
+			var dx, dy int
+			switch {
+			case angle < 22.5 || angle >= 157.5:
+				dx, dy = 1, 0
+			case angle < 67.5:
+				dx, dy = 1, 1
+			case angle < 112.5:
+				dx, dy = 0, 1
+			default:
+				dx, dy = -1, 1
+			}
+			n1, n2 := 0.0, 0.0
+			if x+dx >= 0 && x+dx < w && y+dy >= 0 && y+dy < h {
+				n1 = g.normalized((y+dy)*w + x + dx)
+			}
+			if x-dx >= 0 && x-dx < w && y-dy >= 0 && y-dy < h {
+				n2 = g.normalized((y-dy)*w + x - dx)
+			}
+			if m >= n1 && m >= n2 {
+				thin[i] = m
+			}
+		}
+	}
+
+	// Step 5: hysteresis - keep weak pixels connected to strong ones.
+	edge := make([]bool, w*h)
+	var stack []int
+	for i, m := range thin {
+		if m >= high && m > 0 {
+			edge[i] = true
+			stack = append(stack, i)
+		}
+	}
+	for len(stack) > 0 {
+		i := stack[len(stack)-1]
+		stack = stack[:len(stack)-1]
+		x, y := i%w, i/w
+		for _, d := range dirs8 {
+			nx, ny := x+d.X, y+d.Y
+			if nx < 0 || nx >= w || ny < 0 || ny >= h {
+				continue
+			}
+			j := ny*w + nx
+			if !edge[j] && thin[j] >= low && thin[j] > 0 {
+				edge[j] = true
+				stack = append(stack, j)
+			}
+		}
+	}
+
+	return maskWhere(ctx, bounds, func(x, y int) bool {
+		return edge[(y-bounds.Min.Y)*w+(x-bounds.Min.X)]
+	})
+}
+
+// gaussianBlur smooths a w x h plane with a separable Gaussian kernel.
+func gaussianBlur(plane []float64, w, h int, sigma float64) []float64 {
+	r := int(math.Ceil(3 * sigma))
+	kernel := make([]float64, 2*r+1)
+	var sum float64
+	for i := -r; i <= r; i++ {
+		kernel[i+r] = math.Exp(-float64(i*i) / (2 * sigma * sigma))
+		sum += kernel[i+r]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+
+	tmp := make([]float64, w*h)
+	out := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var v float64
+			for k := -r; k <= r; k++ {
+				v += kernel[k+r] * plane[y*w+min(max(x+k, 0), w-1)]
+			}
+			tmp[y*w+x] = v
+		}
+	}
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			var v float64
+			for k := -r; k <= r; k++ {
+				v += kernel[k+r] * tmp[min(max(y+k, 0), h-1)*w+x]
+			}
+			out[y*w+x] = v
+		}
+	}
+	return out
+}
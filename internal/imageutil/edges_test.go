@@ -0,0 +1,88 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// darkSquare returns a 40x40 white image with a black 20x20 square at (10,10).
+func darkSquare() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 40, 40))
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			v := uint8(255)
+			if x >= 10 && x < 30 && y >= 10 && y < 30 {
+				v = 0
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+func TestCanny(t *testing.T) {
+	ctx := context.Background()
+	edges, err := Canny(ctx, darkSquare(), DefaultCannyOptions)
+	if err != nil {
+		t.Fatalf("got error while detecting edges:\n%s", err.Error())
+	}
+
+	// edges must hug the square border and leave flat areas empty
+	n := 0
+	for y := 0; y < 40; y++ {
+		for x := 0; x < 40; x++ {
+			if !isBlack(edges, x, y) {
+				continue
+			}
+			n++
+			nearBorder := x >= 8 && x <= 31 && (y >= 8 && y <= 31)
+			inside := x >= 12 && x < 28 && y >= 12 && y < 28
+			if !nearBorder || inside {
+				t.Fatalf("unexpected edge pixel at (%d,%d)", x, y)
+			}
+		}
+	}
+	if n < 60 {
+		t.Fatalf("got %d edge pixels, want the whole square outline", n)
+	}
+
+	// the edge map is a closed ring: one outer border and one hole
+	contours, err := FindContours(ctx, edges)
+	if err != nil {
+		t.Fatalf("got error while tracing contours:\n%s", err.Error())
+	}
+	outer := 0
+	for _, c := range contours {
+		if !c.Hole {
+			outer++
+		}
+	}
+	if outer != 1 {
+		t.Fatalf("got %d outer contours, want 1", outer)
+	}
+}
+
+func TestGradientEdges(t *testing.T) {
+	for _, op := range []EdgeOperator{Sobel, Scharr} {
+		edges, err := GradientEdges(context.Background(), darkSquare(), op)
+		if err != nil {
+			t.Fatalf("got error while detecting edges:\n%s", err.Error())
+		}
+		if !isBlack(edges, 10, 20) || isBlack(edges, 20, 20) || isBlack(edges, 2, 2) {
+			t.Fatalf("operator %d: edge map does not follow the square border", op)
+		}
+	}
+
+	// the reported level is the one the edge map was cut at
+	for _, mode := range []SegmentMode{ModeSobel, ModeScharr} {
+		level, ok, err := SegmentThreshold(context.Background(), darkSquare(), SegmentOptions{Mode: mode})
+		if err != nil {
+			t.Fatalf("got error while finding the %s threshold:\n%s", mode, err.Error())
+		}
+		if !ok || level <= 0 || level >= 255 {
+			t.Fatalf("got %s threshold %d (%v), want a level inside the magnitude range", mode, level, ok)
+		}
+	}
+}
@@ -0,0 +1,93 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"math"
+	"sort"
+)
+
+// -----------------------------------------------------------------------------
+// Flatbed splitting
+// -----------------------------------------------------------------------------
+
+// FlatbedOptions - settings for SplitFlatbed.
+type FlatbedOptions struct {
+	MinSize int           // objects whose box is shorter than this on either side are skipped
+	Padding int           // pixels added around every box; negative values trim the edges
+	Interp  Interpolation // resampling used to straighten the objects
+}
+
+// DefaultFlatbedOptions - defaults for SplitFlatbed.
+var DefaultFlatbedOptions = FlatbedOptions{MinSize: 50, Interp: Bicubic}
+
+// FlatbedObject - one object found on a flatbed scan.
+type FlatbedObject struct {
+	Contour Contour     // outer border of the object
+	Box     RotatedRect // padded minimum-area box
+	Image   image.Image // the straightened crop
+}
+
+// SplitFlatbed finds the large objects (photos, receipts) of a flatbed scan
+// and returns each one straightened and cropped to its rotated bounding box.
+// bin is the binarized scan with the objects black on a white lid; the
+// objects are returned in reading order.
+func SplitFlatbed(ctx context.Context, src, bin image.Image, opts FlatbedOptions) ([]FlatbedObject, error) {
+	contours, err := FindContours(ctx, bin)
+	if err != nil {
+		return nil, err
+	}
+
+	var objs []FlatbedObject
+	for _, c := range contours {
+		// only top-level objects; anything inside belongs to the picture
+		if c.Hole || c.Parent != 0 {
+			continue
+		}
+		box := MinAreaRect(toPointsF(c.Points))
+		if box.W+1 < float64(opts.MinSize) || box.H+1 < float64(opts.MinSize) {
+			continue
+		}
+		// contour points are pixel indices; the pixels themselves cover [x, x+1)
+		box.Center = box.Center.Add(PointF{0.5, 0.5})
+		box = box.Inflate(0.5 + float64(opts.Padding))
+		objs = append(objs, FlatbedObject{Contour: c, Box: box})
+	}
+
+	readingOrder(objs)
+
+	for i := range objs {
+		box := objs[i].Box
+		w := max(int(math.Round(box.W)), 1)
+		h := max(int(math.Round(box.H)), 1)
+		objs[i].Image, err = WarpPerspective(ctx, src, box.Corners(), w, h, opts.Interp)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return objs, nil
+}
+
+// readingOrder sorts objs into rows top to bottom, each row left to right.
+// A row starts at the topmost object left over and takes every object
+// whose centre lies within that object's vertical extent.
+func readingOrder(objs []FlatbedObject) {
+	sort.SliceStable(objs, func(i, j int) bool {
+		return objs[i].Box.Center.Y < objs[j].Box.Center.Y
+	})
+	for start := 0; start < len(objs); {
+		bottom := math.Inf(-1)
+		for _, c := range objs[start].Box.Corners() {
+			bottom = math.Max(bottom, c.Y)
+		}
+		end := start + 1
+		for end < len(objs) && objs[end].Box.Center.Y < bottom {
+			end++
+		}
+		row := objs[start:end]
+		sort.SliceStable(row, func(i, j int) bool {
+			return row[i].Box.Center.X < row[j].Box.Center.X
+		})
+		start = end
+	}
+}
@@ -0,0 +1,100 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// flatbedScan returns a white 320x240 scan with a dark upright 80x60 photo
+// on the right and a 100x70 photo turned by 12° on the left.
+func flatbedScan() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 320, 240))
+	tilted := RotatedRect{
+		Center: PointF{80, 120},
+		Axis:   PointF{math.Cos(12 * math.Pi / 180), math.Sin(12 * math.Pi / 180)},
+		W:      100, H: 70,
+	}.Corners()
+	for y := 0; y < 240; y++ {
+		for x := 0; x < 320; x++ {
+			p := PointF{float64(x) + 0.5, float64(y) + 0.5}
+			v := uint8(250)
+			if insidePolygon(p, tilted[:]) || (x >= 200 && x < 280 && y >= 90 && y < 150) {
+				v = 30
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+func TestMinAreaRect(t *testing.T) {
+	want := RotatedRect{Center: PointF{50, 40}, Axis: PointF{0.8, 0.6}, W: 40, H: 20}
+	q := want.Corners()
+	got := MinAreaRect(q[:])
+	if got.Center.Dist(want.Center) > 1e-6 || math.Abs(got.W-want.W) > 1e-6 ||
+		math.Abs(got.H-want.H) > 1e-6 || math.Abs(got.Angle()-want.Angle()) > 1e-6 {
+		t.Fatalf("got %+v, want %+v", got, want)
+	}
+}
+
+func TestSplitFlatbed(t *testing.T) {
+	ctx := context.Background()
+	scan := flatbedScan()
+	bin, err := OtsuBinarize(ctx, scan)
+	if err != nil {
+		t.Fatalf("got error while binarizing:\n%s", err.Error())
+	}
+	objs, err := SplitFlatbed(ctx, scan, bin, DefaultFlatbedOptions)
+	if err != nil {
+		t.Fatalf("got error while splitting scan:\n%s", err.Error())
+	}
+	if len(objs) != 2 {
+		t.Fatalf("got %d objects, want 2", len(objs))
+	}
+
+	want := []struct {
+		w, h  int
+		angle float64
+	}{{100, 70, 12}, {80, 60, 0}}
+	for i, o := range objs {
+		b := o.Image.Bounds()
+		if abs(b.Dx()-want[i].w) > 2 || abs(b.Dy()-want[i].h) > 2 {
+			t.Fatalf("object %d: got %dx%d, want %dx%d", i, b.Dx(), b.Dy(), want[i].w, want[i].h)
+		}
+		if math.Abs(o.Box.Angle()-want[i].angle) > 1 {
+			t.Fatalf("object %d: got angle %.1f°, want %.1f°", i, o.Box.Angle(), want[i].angle)
+		}
+		// the straightened photo is dark right up to its edges
+		for _, p := range []image.Point{{3, 3}, {b.Dx() / 2, b.Dy() / 2}, {b.Dx() - 4, b.Dy() - 4}} {
+			if y := color.GrayModel.Convert(o.Image.At(p.X, p.Y)).(color.Gray).Y; y > 80 {
+				t.Fatalf("object %d: pixel %v is %d, want photo dark", i, p, y)
+			}
+		}
+	}
+}
+
+func TestReadingOrder(t *testing.T) {
+	// two rows of boxes with ragged tops; the tall box on the right of the
+	// first row reaches down to the second row without pulling it in
+	box := func(x, y, h float64) FlatbedObject {
+		return FlatbedObject{Box: RotatedRect{Center: PointF{x, y}, Axis: PointF{1, 0}, W: 50, H: h}}
+	}
+	objs := []FlatbedObject{
+		box(200, 140, 50), // row 2, right
+		box(140, 60, 60),  // row 1, middle
+		box(260, 80, 120), // row 1, right
+		box(20, 150, 50),  // row 2, left
+		box(30, 50, 80),   // row 1, left
+	}
+	readingOrder(objs)
+
+	want := []PointF{{30, 50}, {140, 60}, {260, 80}, {20, 150}, {200, 140}}
+	for i, o := range objs {
+		if o.Box.Center != want[i] {
+			t.Fatalf("object %d: got centre %v, want %v", i, o.Box.Center, want[i])
+		}
+	}
+}
@@ -0,0 +1,299 @@
+package imageutil
+
+import (
+	"context"
+	"encoding/csv"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"math/cmplx"
+	"strconv"
+)
+
+// -----------------------------------------------------------------------------
+// Contour resampling
+// -----------------------------------------------------------------------------
+
+// ResampleContour returns n points spaced equally along the closed polygon
+// pts, starting at pts[0].
+func ResampleContour(pts []PointF, n int) []PointF {
+	if len(pts) == 0 || n <= 0 {
+		return nil
+	}
+	total := Perimeter(pts)
+	out := make([]PointF, 0, n)
+	if total == 0 {
+		for range n {
+			out = append(out, pts[0])
+		}
+		return out
+	}
+
+	step := total / float64(n)
+	seg, pos := 0, 0.0 // current edge and arc length at its start
+	for i := range n {
+		target := float64(i) * step
+		for {
+			a, b := pts[seg], pts[(seg+1)%len(pts)]
+			l := a.Dist(b)
+			if target <= pos+l || seg == len(pts)-1 {
+				t := 0.0
+				if l > 0 {
+					t = math.Min(1, (target-pos)/l)
+				}
+				out = append(out, a.Lerp(b, t))
+				break
+			}
+			pos += l
+			seg++
+		}
+	}
+	return out
+}
+
+// -----------------------------------------------------------------------------
+// Fourier descriptors
+// -----------------------------------------------------------------------------
+
+const (
+	fourierSamples   = 128 // contour points fed to the transform
+	fourierHarmonics = 16  // harmonics kept on each side of the spectrum
+)
+
+// FourierDescriptors returns the magnitudes of the first harmonics of the
+// contour read as a complex signal x + iy, divided by the first harmonic.
+// Dropping the DC term removes translation, dividing removes scale and
+// keeping only magnitudes removes rotation and the choice of start point.
+func FourierDescriptors(pts []PointF, harmonics int) []float64 {
+	ring := ResampleContour(clockwise(pts), fourierSamples)
+	if len(ring) == 0 {
+		return nil
+	}
+	coef := func(k int) complex128 {
+		var sum complex128
+		for i, p := range ring {
+			angle := -2 * math.Pi * float64(k*i) / float64(len(ring))
+			sum += complex(p.X, p.Y) * cmplx.Rect(1, angle)
+		}
+		return sum / complex(float64(len(ring)), 0)
+	}
+
+	scale := cmplx.Abs(coef(1))
+	if scale == 0 {
+		return make([]float64, 2*harmonics-1)
+	}
+	fd := make([]float64, 0, 2*harmonics-1)
+	for k := 1; k <= harmonics; k++ {
+		if k > 1 {
+			fd = append(fd, cmplx.Abs(coef(k))/scale)
+		}
+		fd = append(fd, cmplx.Abs(coef(-k))/scale)
+	}
+	return fd
+}
+
+// clockwise returns pts in clockwise screen order, reversing a copy if needed.
+func clockwise(pts []PointF) []PointF {
+	if SignedArea(pts) >= 0 {
+		return pts
+	}
+	out := make([]PointF, len(pts))
+	for i, p := range pts {
+		out[len(pts)-1-i] = p
+	}
+	return out
+}
+
+// -----------------------------------------------------------------------------
+// Elliptic Fourier descriptors
+// -----------------------------------------------------------------------------
+
+// EllipticHarmonic - coefficients of one harmonic of an elliptic Fourier
+// series: x += A*cos + B*sin, y += C*cos + D*sin.
+type EllipticHarmonic struct {
+	A, B, C, D float64
+}
+
+// EllipticFourier - elliptic Fourier series of a closed contour
+// (Kuhl & Giardina, 1982).
+type EllipticFourier struct {
+	Center    PointF // DC term
+	Harmonics []EllipticHarmonic
+}
+
+// EllipticFourierDescriptors computes the first order harmonics of the
+// closed polygon pts, parametrised by arc length.
+func EllipticFourierDescriptors(pts []PointF, order int) EllipticFourier {
+	var ef EllipticFourier
+	total := Perimeter(pts)
+	if len(pts) < 2 || total == 0 {
+		ef.Harmonics = make([]EllipticHarmonic, order)
+		return ef
+	}
+
+	// DC term: mean of the contour sampled evenly along its length
+	for _, p := range ResampleContour(pts, fourierSamples) {
+		ef.Center = ef.Center.Add(p)
+	}
+	ef.Center = ef.Center.Mul(1.0 / fourierSamples)
+
+	for n := 1; n <= order; n++ {
+		var h EllipticHarmonic
+		k := total / (2 * float64(n*n) * math.Pi * math.Pi)
+		t := 0.0
+		for i := range pts {
+			d := pts[(i+1)%len(pts)].Sub(pts[i])
+			dt := d.Len()
+			if dt == 0 {
+				continue
+			}
+			phi0 := 2 * math.Pi * float64(n) * t / total
+			t += dt
+			phi1 := 2 * math.Pi * float64(n) * t / total
+			dcos, dsin := math.Cos(phi1)-math.Cos(phi0), math.Sin(phi1)-math.Sin(phi0)
+			h.A += d.X / dt * dcos
+			h.B += d.X / dt * dsin
+			h.C += d.Y / dt * dcos
+			h.D += d.Y / dt * dsin
+		}
+		ef.Harmonics = append(ef.Harmonics, EllipticHarmonic{h.A * k, h.B * k, h.C * k, h.D * k})
+	}
+	return ef
+}
+
+// Normalize returns the descriptors made independent of the start point,
+// rotation, size and position of the contour: the first harmonic ellipse
+// becomes a unit-length major axis along x, starting at its end, and the
+// centre moves to the origin. Either end of the axis may be chosen, so the
+// even harmonics are only defined up to their sign.
+func (ef EllipticFourier) Normalize() EllipticFourier {
+	out := EllipticFourier{Harmonics: make([]EllipticHarmonic, len(ef.Harmonics))}
+	if len(ef.Harmonics) == 0 {
+		return out
+	}
+	h1 := ef.Harmonics[0]
+	// start point: shift the parameter to the end of the major axis
+	theta := 0.5 * math.Atan2(2*(h1.A*h1.B+h1.C*h1.D), h1.A*h1.A-h1.B*h1.B+h1.C*h1.C-h1.D*h1.D)
+	for i, h := range ef.Harmonics {
+		sin, cos := math.Sincos(float64(i+1) * theta)
+		out.Harmonics[i] = EllipticHarmonic{
+			A: h.A*cos + h.B*sin, B: -h.A*sin + h.B*cos,
+			C: h.C*cos + h.D*sin, D: -h.C*sin + h.D*cos,
+		}
+	}
+
+	// rotation: turn the major axis onto x
+	a1 := out.Harmonics[0]
+	psi := math.Atan2(a1.C, a1.A)
+	scale := math.Hypot(a1.A, a1.C)
+	if scale == 0 {
+		return out
+	}
+	sin, cos := math.Sincos(psi)
+	for i, h := range out.Harmonics {
+		out.Harmonics[i] = EllipticHarmonic{
+			A: (cos*h.A + sin*h.C) / scale, B: (cos*h.B + sin*h.D) / scale,
+			C: (-sin*h.A + cos*h.C) / scale, D: (-sin*h.B + cos*h.D) / scale,
+		}
+	}
+	return out
+}
+
+// Reconstruct returns n points of the outline described by the first k
+// harmonics; fewer harmonics give smoother outlines. With k <= 0 every
+// point is the centre.
+func (ef EllipticFourier) Reconstruct(k, n int) []PointF {
+	k = max(0, min(k, len(ef.Harmonics)))
+	pts := make([]PointF, n)
+	for i := range pts {
+		t := 2 * math.Pi * float64(i) / float64(n)
+		p := ef.Center
+		for j, h := range ef.Harmonics[:k] {
+			sin, cos := math.Sincos(float64(j+1) * t)
+			p = p.Add(PointF{h.A*cos + h.B*sin, h.C*cos + h.D*sin})
+		}
+		pts[i] = p
+	}
+	return pts
+}
+
+// -----------------------------------------------------------------------------
+// Per-contour descriptors
+// -----------------------------------------------------------------------------
+
+// ContourDescriptor - elliptic Fourier descriptors of one contour.
+type ContourDescriptor struct {
+	ID      int
+	Parent  int
+	Hole    bool
+	Fourier EllipticFourier
+}
+
+// DescribeContours resamples every contour to samples points spaced evenly
+// along its length and computes order harmonics of its elliptic Fourier
+// series.
+func DescribeContours(ctx context.Context, contours []Contour, samples, order int) ([]ContourDescriptor, error) {
+	ds := make([]ContourDescriptor, 0, len(contours))
+	for _, c := range contours {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		ring := ResampleContour(toPointsF(c.Points), samples)
+		ds = append(ds, ContourDescriptor{
+			ID:      c.ID,
+			Parent:  c.Parent,
+			Hole:    c.Hole,
+			Fourier: EllipticFourierDescriptors(ring, order),
+		})
+	}
+	return ds, nil
+}
+
+// WriteDescriptorsCSV writes one row per contour: id, parent, hole, the
+// centre and a, b, c, d of every harmonic.
+func WriteDescriptorsCSV(w io.Writer, ds []ContourDescriptor) error {
+	order := 0
+	for _, d := range ds {
+		order = max(order, len(d.Fourier.Harmonics))
+	}
+	header := []string{"id", "parent", "hole", "cx", "cy"}
+	for n := 1; n <= order; n++ {
+		header = append(header, fmt.Sprintf("a%d", n), fmt.Sprintf("b%d", n), fmt.Sprintf("c%d", n), fmt.Sprintf("d%d", n))
+	}
+
+	cw := csv.NewWriter(w)
+	cw.Write(header)
+	ff := func(v float64) string { return strconv.FormatFloat(v, 'g', 8, 64) }
+	for _, d := range ds {
+		row := []string{strconv.Itoa(d.ID), strconv.Itoa(d.Parent), strconv.FormatBool(d.Hole),
+			ff(d.Fourier.Center.X), ff(d.Fourier.Center.Y)}
+		for n := range order {
+			var h EllipticHarmonic
+			if n < len(d.Fourier.Harmonics) {
+				h = d.Fourier.Harmonics[n]
+			}
+			row = append(row, ff(h.A), ff(h.B), ff(h.C), ff(h.D))
+		}
+		cw.Write(row)
+	}
+	cw.Flush()
+	return cw.Error()
+}
+
+// DrawFourierOutlines draws every contour reconstructed from its first k
+// harmonics on a white image: smooth versions of the traced outlines.
+func DrawFourierOutlines(ctx context.Context, bounds image.Rectangle, ds []ContourDescriptor, k int) (image.Image, error) {
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, image.White, image.Point{}, draw.Src)
+	red := color.RGBA{R: 255, A: 255}
+	for _, d := range ds {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		drawRing(dst, d.Fourier.Reconstruct(k, fourierSamples), red)
+	}
+	return dst, ctx.Err()
+}
@@ -0,0 +1,107 @@
+package imageutil
+
+import (
+	"bytes"
+	"context"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestResampleContour(t *testing.T) {
+	square := []PointF{{0, 0}, {10, 0}, {10, 10}, {0, 10}}
+	pts := ResampleContour(square, 8)
+	want := []PointF{{0, 0}, {5, 0}, {10, 0}, {10, 5}, {10, 10}, {5, 10}, {0, 10}, {0, 5}}
+	for i := range want {
+		if pts[i].Dist(want[i]) > 1e-9 {
+			t.Fatalf("got %v, want %v", pts, want)
+		}
+	}
+
+	// descriptors ignore position, size and rotation
+	rot := make([]PointF, len(square))
+	for i, p := range square {
+		s, c := math.Sincos(0.6)
+		rot[i] = PointF{3 * (p.X*c - p.Y*s), 3 * (p.X*s + p.Y*c)}.Add(PointF{50, 70})
+	}
+	a, b := FourierDescriptors(square, 8), FourierDescriptors(rot, 8)
+	for i := range a {
+		if math.Abs(a[i]-b[i]) > 1e-2 {
+			t.Fatalf("descriptor %d: %.4f vs %.4f", i, a[i], b[i])
+		}
+	}
+}
+
+// rotated returns pts turned by angle radians, scaled by k and moved by off.
+func rotated(pts []PointF, angle, k float64, off PointF) []PointF {
+	s, c := math.Sincos(angle)
+	out := make([]PointF, len(pts))
+	for i, p := range pts {
+		out[i] = PointF{k * (p.X*c - p.Y*s), k * (p.X*s + p.Y*c)}.Add(off)
+	}
+	return out
+}
+
+func TestEllipticFourier(t *testing.T) {
+	// a few harmonics reproduce a smooth outline
+	ellipse := make([]PointF, 200)
+	for i := range ellipse {
+		a := 2 * math.Pi * float64(i) / 200
+		ellipse[i] = PointF{60 + 40*math.Cos(a), 50 + 20*math.Sin(a)}
+	}
+	ef := EllipticFourierDescriptors(ellipse, 10)
+	if ef.Center.Dist(PointF{60, 50}) > 0.1 {
+		t.Fatalf("got centre %v, want (60,50)", ef.Center)
+	}
+	for i, p := range ef.Reconstruct(10, 50) {
+		d := p.Sub(PointF{60, 50})
+		if r := (d.X/40)*(d.X/40) + (d.Y/20)*(d.Y/20); math.Abs(r-1) > 0.01 {
+			t.Fatalf("reconstructed point %d %v is off the ellipse", i, p)
+		}
+	}
+	// no harmonics leave only the centre
+	for _, p := range ef.Reconstruct(-1, 4) {
+		if p != ef.Center {
+			t.Fatalf("got %v from no harmonics, want the centre %v", p, ef.Center)
+		}
+	}
+
+	// normalised descriptors ignore position, size, rotation and start point
+	pentagon := ResampleContour(regularPolygon(5, 40, 40, 0.1), 128)
+	shifted := append(append([]PointF(nil), pentagon[37:]...), pentagon[:37]...)
+	moved := rotated(shifted, 1.1, 2.5, PointF{300, -20})
+	a := EllipticFourierDescriptors(pentagon, 8).Normalize()
+	b := EllipticFourierDescriptors(moved, 8).Normalize()
+	for n := range a.Harmonics {
+		ha, hb := a.Harmonics[n], b.Harmonics[n]
+		if n%2 == 1 { // even harmonics are only defined up to their sign
+			ha = EllipticHarmonic{math.Abs(ha.A), math.Abs(ha.B), math.Abs(ha.C), math.Abs(ha.D)}
+			hb = EllipticHarmonic{math.Abs(hb.A), math.Abs(hb.B), math.Abs(hb.C), math.Abs(hb.D)}
+		}
+		if math.Abs(ha.A-hb.A)+math.Abs(ha.B-hb.B)+math.Abs(ha.C-hb.C)+math.Abs(ha.D-hb.D) > 0.02 {
+			t.Fatalf("harmonic %d: %+v vs %+v", n+1, ha, hb)
+		}
+	}
+	if h := a.Harmonics[0]; math.Abs(h.A-1) > 1e-9 || math.Abs(h.C) > 1e-9 {
+		t.Fatalf("first normalised harmonic is %+v, want A=1, C=0", h)
+	}
+}
+
+func TestWriteDescriptorsCSV(t *testing.T) {
+	contours, err := FindContours(context.Background(), filledShape(polygonShape(regularPolygon(6, 50, 50, 0))))
+	if err != nil {
+		t.Fatalf("got error while finding contours:\n%s", err.Error())
+	}
+	ds, err := DescribeContours(context.Background(), contours, 64, 3)
+	if err != nil {
+		t.Fatalf("got error while describing contours:\n%s", err.Error())
+	}
+	var buf bytes.Buffer
+	if err := WriteDescriptorsCSV(&buf, ds); err != nil {
+		t.Fatalf("got error while writing CSV:\n%s", err.Error())
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if lines[0] != "id,parent,hole,cx,cy,a1,b1,c1,d1,a2,b2,c2,d2,a3,b3,c3,d3" || len(lines) != len(ds)+1 {
+		t.Fatalf("unexpected CSV:\n%s", buf.String())
+	}
+}
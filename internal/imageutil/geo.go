@@ -0,0 +1,250 @@
+package imageutil
+
+import (
+	"bufio"
+	"encoding/json"
+	"fmt"
+	"io"
+	"path/filepath"
+	"strconv"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Georeferencing
+// -----------------------------------------------------------------------------
+
+// GeoTransform - affine map from pixel to world coordinates in GDAL order:
+//
+//	X = T[0] + px*T[1] + py*T[2]
+//	Y = T[3] + px*T[4] + py*T[5]
+//
+// where (px, py) is measured from the top-left corner of the image.
+type GeoTransform [6]float64
+
+// IdentityGeoTransform - keeps pixel coordinates.
+var IdentityGeoTransform = GeoTransform{0, 1, 0, 0, 0, 1}
+
+// Apply maps a pixel position to world coordinates.
+func (t GeoTransform) Apply(p PointF) PointF {
+	return PointF{t[0] + p.X*t[1] + p.Y*t[2], t[3] + p.X*t[4] + p.Y*t[5]}
+}
+
+// ParseGeoTransform reads six comma-separated numbers in GDAL order.
+func ParseGeoTransform(s string) (GeoTransform, error) {
+	var t GeoTransform
+	parts := strings.Split(s, ",")
+	if len(parts) != 6 {
+		return t, fmt.Errorf("geotransform %q: want 6 comma-separated numbers", s)
+	}
+	for i, p := range parts {
+		v, err := strconv.ParseFloat(strings.TrimSpace(p), 64)
+		if err != nil {
+			return t, fmt.Errorf("geotransform %q: %w", s, err)
+		}
+		t[i] = v
+	}
+	return t, nil
+}
+
+// ReadWorldFile parses an ESRI world file (.tfw, .pgw, ...): six lines with
+// the pixel size and rotation terms A, D, B, E and the world position C, F
+// of the centre of the top-left pixel.
+func ReadWorldFile(r io.Reader) (GeoTransform, error) {
+	var v []float64
+	sc := bufio.NewScanner(r)
+	for sc.Scan() && len(v) < 6 {
+		line := strings.TrimSpace(sc.Text())
+		if line == "" {
+			continue
+		}
+		f, err := strconv.ParseFloat(line, 64)
+		if err != nil {
+			return GeoTransform{}, fmt.Errorf("world file: %w", err)
+		}
+		v = append(v, f)
+	}
+	if err := sc.Err(); err != nil {
+		return GeoTransform{}, err
+	}
+	if len(v) < 6 {
+		return GeoTransform{}, fmt.Errorf("world file: got %d values, want 6", len(v))
+	}
+	a, d, b, e, c, f := v[0], v[1], v[2], v[3], v[4], v[5]
+	// move the origin from the pixel centre to its corner
+	return GeoTransform{c - a/2 - b/2, a, b, f - d/2 - e/2, d, e}, nil
+}
+
+// WorldFileNames returns the usual world file names for an image, most
+// common first: first and last letter of the extension plus "w" (.pgw for
+// .png, .tfw for .tiff) and the whole extension plus "w" (.pngw).
+func WorldFileNames(imagePath string) []string {
+	ext := filepath.Ext(imagePath)
+	if len(ext) < 2 {
+		return nil
+	}
+	base := strings.TrimSuffix(imagePath, ext)
+	return []string{base + ext[:2] + ext[len(ext)-1:] + "w", imagePath + "w"}
+}
+
+// -----------------------------------------------------------------------------
+// Polygons
+// -----------------------------------------------------------------------------
+
+// GeoPolygon - one object as a polygon with holes in world coordinates.
+// Rings are closed (first point repeated last); the outer ring runs
+// counter-clockwise and the holes clockwise, with y pointing up.
+type GeoPolygon struct {
+	ID    int
+	Outer []PointF
+	Holes [][]PointF
+}
+
+// BuildPolygons turns every outer contour and its holes into a polygon,
+// through the pixel centres mapped by t. Contours with fewer than three
+// points enclose no area and are dropped.
+func BuildPolygons(contours []Contour, t GeoTransform) []GeoPolygon {
+	holes := make(map[int][]Contour)
+	for _, c := range contours {
+		if c.Hole {
+			holes[c.Parent] = append(holes[c.Parent], c)
+		}
+	}
+	var polys []GeoPolygon
+	for _, c := range contours {
+		if c.Hole || len(c.Points) < 3 {
+			continue
+		}
+		p := GeoPolygon{ID: c.ID, Outer: geoRing(c, t, true)}
+		for _, h := range holes[c.ID] {
+			if len(h.Points) >= 3 {
+				p.Holes = append(p.Holes, geoRing(h, t, false))
+			}
+		}
+		polys = append(polys, p)
+	}
+	return polys
+}
+
+// geoRing maps the contour to world coordinates as a closed ring running
+// counter-clockwise (ccw) or clockwise in a y-up frame.
+func geoRing(c Contour, t GeoTransform, ccw bool) []PointF {
+	ring := make([]PointF, 0, len(c.Points)+1)
+	for _, q := range c.Points {
+		ring = append(ring, t.Apply(Pf(q).Add(PointF{0.5, 0.5})))
+	}
+	if (SignedArea(ring) > 0) != ccw {
+		for i, j := 0, len(ring)-1; i < j; i, j = i+1, j-1 {
+			ring[i], ring[j] = ring[j], ring[i]
+		}
+	}
+	return append(ring, ring[0])
+}
+
+// Area returns the area of the polygon without its holes, in world units.
+func (p GeoPolygon) Area() float64 {
+	a := PolygonArea(p.Outer)
+	for _, h := range p.Holes {
+		a -= PolygonArea(h)
+	}
+	return a
+}
+
+// -----------------------------------------------------------------------------
+// GeoJSON and WKT output
+// -----------------------------------------------------------------------------
+
+type geoJSONGeometry struct {
+	Type        string         `json:"type"`
+	Coordinates [][][2]float64 `json:"coordinates"`
+}
+
+type geoJSONFeature struct {
+	Type       string          `json:"type"`
+	Geometry   geoJSONGeometry `json:"geometry"`
+	Properties map[string]any  `json:"properties"`
+}
+
+type geoJSONCollection struct {
+	Type     string           `json:"type"`
+	Features []geoJSONFeature `json:"features"`
+}
+
+// WriteGeoJSON writes the polygons as a GeoJSON FeatureCollection, one
+// Polygon feature per object with its contour id, hole count and area.
+func WriteGeoJSON(w io.Writer, polys []GeoPolygon) error {
+	fc := geoJSONCollection{Type: "FeatureCollection", Features: make([]geoJSONFeature, 0, len(polys))}
+	for _, p := range polys {
+		coords := [][][2]float64{jsonRing(p.Outer)}
+		for _, h := range p.Holes {
+			coords = append(coords, jsonRing(h))
+		}
+		fc.Features = append(fc.Features, geoJSONFeature{
+			Type:     "Feature",
+			Geometry: geoJSONGeometry{Type: "Polygon", Coordinates: coords},
+			Properties: map[string]any{
+				"id":    p.ID,
+				"holes": len(p.Holes),
+				"area":  p.Area(),
+			},
+		})
+	}
+	// compact: indenting puts every coordinate on lines of its own
+	return json.NewEncoder(w).Encode(fc)
+}
+
+func jsonRing(ring []PointF) [][2]float64 {
+	out := make([][2]float64, len(ring))
+	for i, p := range ring {
+		out[i] = [2]float64{p.X, p.Y}
+	}
+	return out
+}
+
+// WriteWKT writes the polygons as Well-Known Text: one POLYGON per line,
+// or a single MULTIPOLYGON holding all of them if multi is set.
+func WriteWKT(w io.Writer, polys []GeoPolygon, multi bool) error {
+	bw := bufio.NewWriter(w)
+	if multi {
+		if len(polys) == 0 {
+			bw.WriteString("MULTIPOLYGON EMPTY\n")
+			return bw.Flush()
+		}
+		bw.WriteString("MULTIPOLYGON (")
+		for i, p := range polys {
+			if i > 0 {
+				bw.WriteString(", ")
+			}
+			writeWKTRings(bw, p)
+		}
+		bw.WriteString(")\n")
+		return bw.Flush()
+	}
+	for _, p := range polys {
+		bw.WriteString("POLYGON ")
+		writeWKTRings(bw, p)
+		bw.WriteString("\n")
+	}
+	return bw.Flush()
+}
+
+// writeWKTRings writes "((outer), (hole), ...)".
+func writeWKTRings(w *bufio.Writer, p GeoPolygon) {
+	w.WriteString("(")
+	for i, ring := range append([][]PointF{p.Outer}, p.Holes...) {
+		if i > 0 {
+			w.WriteString(", ")
+		}
+		w.WriteString("(")
+		for j, q := range ring {
+			if j > 0 {
+				w.WriteString(", ")
+			}
+			w.WriteString(strconv.FormatFloat(q.X, 'f', -1, 64))
+			w.WriteString(" ")
+			w.WriteString(strconv.FormatFloat(q.Y, 'f', -1, 64))
+		}
+		w.WriteString(")")
+	}
+	w.WriteString(")")
+}
@@ -0,0 +1,109 @@
+package imageutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+	"testing"
+)
+
+func TestReadWorldFile(t *testing.T) {
+	// 0.5 m pixels, top-left pixel centred at (1000.25, 2000.75)
+	wf := "0.5\n0\n0\n-0.5\n1000.25\n2000.75\n"
+	gt, err := ReadWorldFile(strings.NewReader(wf))
+	if err != nil {
+		t.Fatalf("got error while reading world file:\n%s", err.Error())
+	}
+	want := GeoTransform{1000, 0.5, 0, 2001, 0, -0.5}
+	if gt != want {
+		t.Errorf("got %v, want %v", gt, want)
+	}
+	if p := gt.Apply(PointF{0.5, 0.5}); p != (PointF{1000.25, 2000.75}) {
+		t.Errorf("top-left pixel centre maps to %v", p)
+	}
+
+	if _, err := ReadWorldFile(strings.NewReader("1\n0\n0\n")); err == nil {
+		t.Errorf("expected an error for a short world file")
+	}
+	if got := WorldFileNames("/data/tile.png"); got[0] != "/data/tile.pgw" || got[1] != "/data/tile.pngw" {
+		t.Errorf("got world file names %v", got)
+	}
+}
+
+func TestBuildPolygons(t *testing.T) {
+	// 10x10 frame with a 4x4 hole
+	mask := image.NewGray(image.Rect(0, 0, 20, 20))
+	for y := range 20 {
+		for x := range 20 {
+			v := uint8(255)
+			if x >= 5 && x < 15 && y >= 5 && y < 15 && !(x >= 8 && x < 12 && y >= 8 && y < 12) {
+				v = 0
+			}
+			mask.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	contours, err := FindContours(context.Background(), mask)
+	if err != nil {
+		t.Fatalf("got error while finding contours:\n%s", err.Error())
+	}
+
+	gt := GeoTransform{100, 2, 0, 500, 0, -2}
+	polys := BuildPolygons(contours, gt)
+	if len(polys) != 1 || len(polys[0].Holes) != 1 {
+		t.Fatalf("got %d polygons, want 1 with 1 hole", len(polys))
+	}
+	p := polys[0]
+	for _, ring := range append([][]PointF{p.Outer}, p.Holes...) {
+		if ring[0] != ring[len(ring)-1] {
+			t.Errorf("ring is not closed")
+		}
+	}
+	if SignedArea(p.Outer) <= 0 || SignedArea(p.Holes[0]) >= 0 {
+		t.Errorf("got ring areas %.1f and %.1f, want outer counter-clockwise and hole clockwise",
+			SignedArea(p.Outer), SignedArea(p.Holes[0]))
+	}
+	// centre line polygons, 4 units per pixel: the 9x9 frame minus the 5x5
+	// hole border, whose 8-connected trace cuts its four corners
+	if a := p.Area(); math.Abs(a-(81-23)*4) > 1e-9 {
+		t.Errorf("got area %.1f, want %d", a, (81-23)*4)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteWKT(&buf, polys, false); err != nil {
+		t.Fatalf("got error while writing WKT:\n%s", err.Error())
+	}
+	if wkt := buf.String(); !strings.HasPrefix(wkt, "POLYGON ((") || strings.Count(wkt, "), (") != 1 {
+		t.Errorf("unexpected WKT: %s", wkt)
+	}
+	buf.Reset()
+	if err := WriteWKT(&buf, append(polys, polys...), true); err != nil {
+		t.Fatalf("got error while writing WKT:\n%s", err.Error())
+	}
+	if wkt := buf.String(); !strings.HasPrefix(wkt, "MULTIPOLYGON (((") || strings.Count(wkt, ")), ((") != 1 {
+		t.Errorf("unexpected WKT: %s", wkt)
+	}
+
+	buf.Reset()
+	if err := WriteGeoJSON(&buf, polys); err != nil {
+		t.Fatalf("got error while writing GeoJSON:\n%s", err.Error())
+	}
+	var fc struct {
+		Type     string
+		Features []struct {
+			Geometry struct {
+				Type        string
+				Coordinates [][][2]float64
+			}
+		}
+	}
+	if err := json.Unmarshal(buf.Bytes(), &fc); err != nil {
+		t.Fatalf("got error while parsing GeoJSON:\n%s", err.Error())
+	}
+	if fc.Type != "FeatureCollection" || len(fc.Features) != 1 || len(fc.Features[0].Geometry.Coordinates) != 2 {
+		t.Errorf("unexpected GeoJSON:\n%s", buf.String())
+	}
+}
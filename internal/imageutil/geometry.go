@@ -0,0 +1,258 @@
+package imageutil
+
+import (
+	"image"
+	"math"
+	"sort"
+)
+
+// -----------------------------------------------------------------------------
+// Points and polygons
+// -----------------------------------------------------------------------------
+
+// PointF - point or vector with floating-point coordinates.
+type PointF struct {
+	X, Y float64
+}
+
+// Pf converts an integer point to a PointF.
+func Pf(p image.Point) PointF {
+	return PointF{float64(p.X), float64(p.Y)}
+}
+
+func (p PointF) Add(q PointF) PointF             { return PointF{p.X + q.X, p.Y + q.Y} }
+func (p PointF) Sub(q PointF) PointF             { return PointF{p.X - q.X, p.Y - q.Y} }
+func (p PointF) Mul(k float64) PointF            { return PointF{p.X * k, p.Y * k} }
+func (p PointF) Dot(q PointF) float64            { return p.X*q.X + p.Y*q.Y }
+func (p PointF) Cross(q PointF) float64          { return p.X*q.Y - p.Y*q.X }
+func (p PointF) Len() float64                    { return math.Hypot(p.X, p.Y) }
+func (p PointF) Dist(q PointF) float64           { return p.Sub(q).Len() }
+func (p PointF) Round() image.Point              { return image.Pt(int(math.Round(p.X)), int(math.Round(p.Y))) }
+func (p PointF) Lerp(q PointF, t float64) PointF { return p.Add(q.Sub(p).Mul(t)) }
+
+// toPointsF converts integer points to PointF.
+func toPointsF(pts []image.Point) []PointF {
+	out := make([]PointF, len(pts))
+	for i, p := range pts {
+		out[i] = Pf(p)
+	}
+	return out
+}
+
+// SignedArea returns the shoelace area of a closed polygon; positive for
+// clockwise order on screen (y axis pointing down).
+func SignedArea(pts []PointF) float64 {
+	var a float64
+	for i := range pts {
+		a += pts[i].Cross(pts[(i+1)%len(pts)])
+	}
+	return a / 2
+}
+
+// PolygonArea returns the absolute area of a closed polygon.
+func PolygonArea(pts []PointF) float64 {
+	return math.Abs(SignedArea(pts))
+}
+
+// Perimeter returns the length of a closed polygon.
+func Perimeter(pts []PointF) float64 {
+	var l float64
+	for i := range pts {
+		l += pts[i].Dist(pts[(i+1)%len(pts)])
+	}
+	return l
+}
+
+// ConvexHull returns the convex hull of pts in clockwise screen order
+// (Andrew's monotone chain).
+func ConvexHull(pts []PointF) []PointF {
+	if len(pts) < 3 {
+		return append([]PointF(nil), pts...)
+	}
+	s := append([]PointF(nil), pts...)
+	sort.Slice(s, func(i, j int) bool {
+		if s[i].X != s[j].X {
+			return s[i].X < s[j].X
+		}
+		return s[i].Y < s[j].Y
+	})
+
+	turn := func(o, a, b PointF) float64 { return a.Sub(o).Cross(b.Sub(o)) }
+	hull := make([]PointF, 0, 2*len(s))
+	for _, p := range s { // lower hull
+		for len(hull) >= 2 && turn(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	lower := len(hull) + 1
+	for i := len(s) - 2; i >= 0; i-- { // upper hull
+		p := s[i]
+		for len(hull) >= lower && turn(hull[len(hull)-2], hull[len(hull)-1], p) <= 0 {
+			hull = hull[:len(hull)-1]
+		}
+		hull = append(hull, p)
+	}
+	return hull[:len(hull)-1]
+}
+
+// ApproxPolygon simplifies a closed polygon with the Douglas-Peucker
+// algorithm so that no point is farther than epsilon from the result.
+func ApproxPolygon(pts []PointF, epsilon float64) []PointF {
+	if len(pts) < 3 {
+		return append([]PointF(nil), pts...)
+	}
+	// split the ring at the point farthest from the first one
+	far := 0
+	for i, p := range pts {
+		if p.Dist(pts[0]) > pts[far].Dist(pts[0]) {
+			far = i
+		}
+	}
+	ring := append(append([]PointF(nil), pts...), pts[0])
+	a := douglasPeucker(ring[:far+1], epsilon)
+	b := douglasPeucker(ring[far:], epsilon)
+	return append(a[:len(a)-1], b[:len(b)-1]...)
+}
+
+// douglasPeucker simplifies an open polyline, keeping both end points.
+func douglasPeucker(pts []PointF, epsilon float64) []PointF {
+	if len(pts) < 3 {
+		return append([]PointF(nil), pts...)
+	}
+	first, last := pts[0], pts[len(pts)-1]
+	idx, dmax := 0, -1.0
+	for i := 1; i < len(pts)-1; i++ {
+		if d := segmentDistance(pts[i], first, last); d > dmax {
+			idx, dmax = i, d
+		}
+	}
+	if dmax <= epsilon {
+		return []PointF{first, last}
+	}
+	a := douglasPeucker(pts[:idx+1], epsilon)
+	b := douglasPeucker(pts[idx:], epsilon)
+	return append(a[:len(a)-1], b...)
+}
+
+// segmentDistance returns the distance from p to the segment ab.
+func segmentDistance(p, a, b PointF) float64 {
+	ab := b.Sub(a)
+	l2 := ab.Dot(ab)
+	if l2 == 0 {
+		return p.Dist(a)
+	}
+	t := math.Max(0, math.Min(1, p.Sub(a).Dot(ab)/l2))
+	return p.Dist(a.Add(ab.Mul(t)))
+}
+
+// fitLine fits a line through pts by total least squares. It returns a point
+// on the line and the unit direction.
+func fitLine(pts []PointF) (PointF, PointF) {
+	var c PointF
+	for _, p := range pts {
+		c = c.Add(p)
+	}
+	c = c.Mul(1 / float64(len(pts)))
+	var sxx, sxy, syy float64
+	for _, p := range pts {
+		d := p.Sub(c)
+		sxx += d.X * d.X
+		sxy += d.X * d.Y
+		syy += d.Y * d.Y
+	}
+	theta := 0.5 * math.Atan2(2*sxy, sxx-syy)
+	return c, PointF{math.Cos(theta), math.Sin(theta)}
+}
+
+// intersectLines returns the intersection of two lines given as point and
+// direction; ok is false for parallel lines.
+func intersectLines(p1, d1, p2, d2 PointF) (PointF, bool) {
+	den := d1.Cross(d2)
+	if math.Abs(den) < 1e-12 {
+		return PointF{}, false
+	}
+	t := p2.Sub(p1).Cross(d2) / den
+	return p1.Add(d1.Mul(t)), true
+}
+
+// -----------------------------------------------------------------------------
+// Rotated rectangles
+// -----------------------------------------------------------------------------
+
+// RotatedRect - rectangle of size W x H centred on Center, with its W side
+// along the unit vector Axis. Axis is kept within 45° of the x axis.
+type RotatedRect struct {
+	Center PointF
+	Axis   PointF
+	W, H   float64
+}
+
+// Angle returns the rotation of the rectangle in degrees, clockwise on screen.
+func (r RotatedRect) Angle() float64 {
+	return math.Atan2(r.Axis.Y, r.Axis.X) * 180 / math.Pi
+}
+
+// Corners returns the rectangle corners as a Quad (top-left first).
+func (r RotatedRect) Corners() Quad {
+	u := r.Axis.Mul(r.W / 2)
+	v := PointF{-r.Axis.Y, r.Axis.X}.Mul(r.H / 2)
+	return Quad{
+		r.Center.Sub(u).Sub(v),
+		r.Center.Add(u).Sub(v),
+		r.Center.Add(u).Add(v),
+		r.Center.Sub(u).Add(v),
+	}
+}
+
+// Inflate grows the rectangle by d on every side; negative d shrinks it.
+func (r RotatedRect) Inflate(d float64) RotatedRect {
+	r.W = math.Max(0, r.W+2*d)
+	r.H = math.Max(0, r.H+2*d)
+	return r
+}
+
+// MinAreaRect returns the smallest-area rectangle enclosing pts, testing
+// every edge direction of the convex hull (rotating calipers).
+func MinAreaRect(pts []PointF) RotatedRect {
+	hull := ConvexHull(pts)
+	if len(hull) == 0 {
+		return RotatedRect{Axis: PointF{1, 0}}
+	}
+
+	best, bestArea := PointF{1, 0}, math.Inf(1)
+	for i := range hull {
+		e := hull[(i+1)%len(hull)].Sub(hull[i])
+		if e.Len() == 0 {
+			continue
+		}
+		u := e.Mul(1 / e.Len())
+		if r := boxAlong(hull, u); r.W*r.H < bestArea {
+			best, bestArea = u, r.W*r.H
+		}
+	}
+
+	// keep the axis within 45° of horizontal, pointing right
+	if math.Abs(best.X) < math.Abs(best.Y) {
+		best = PointF{best.Y, -best.X}
+	}
+	if best.X < 0 {
+		best = best.Mul(-1)
+	}
+	return boxAlong(hull, best)
+}
+
+// boxAlong returns the bounding rectangle of pts with its W side along the
+// unit vector u.
+func boxAlong(pts []PointF, u PointF) RotatedRect {
+	v := PointF{-u.Y, u.X}
+	minU, maxU := math.Inf(1), math.Inf(-1)
+	minV, maxV := math.Inf(1), math.Inf(-1)
+	for _, p := range pts {
+		a, b := p.Dot(u), p.Dot(v)
+		minU, maxU = math.Min(minU, a), math.Max(maxU, a)
+		minV, maxV = math.Min(minV, b), math.Max(maxV, b)
+	}
+	c := u.Mul((minU + maxU) / 2).Add(v.Mul((minV + maxV) / 2))
+	return RotatedRect{Center: c, Axis: u, W: maxU - minU, H: maxV - minV}
+}
@@ -0,0 +1,209 @@
+package imageutil
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"math/rand"
+)
+
+// -----------------------------------------------------------------------------
+// K-means colour quantization
+// -----------------------------------------------------------------------------
+
+const (
+	kmeansMaxSamples = 20000 // pixels used to fit the centres
+	kmeansMaxIter    = 30
+)
+
+// Cluster - one colour cluster found by KMeansSegment.
+type Cluster struct {
+	Color    color.NRGBA // mean colour of the cluster's pixels
+	Mask     image.Image // cluster pixels black, everything else white
+	Contours []Contour   // contours of Mask
+}
+
+// KMeansResult - output of KMeansSegment.
+type KMeansResult struct {
+	Quantized image.Image // every pixel replaced by its cluster colour
+	Clusters  []Cluster
+}
+
+// KMeansSegment groups the pixels of src into k colour clusters using k-means
+// in Lab space and extracts the contours of every cluster separately.
+// The same seed always gives the same clusters.
+func KMeansSegment(ctx context.Context, src image.Image, k int, seed int64) (*KMeansResult, error) {
+	if k < 1 {
+		return nil, fmt.Errorf("k-means needs at least one cluster, got %d", k)
+	}
+	bounds := src.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	rng := rand.New(rand.NewSource(seed))
+
+	// Step 1: convert every pixel to Lab.
+	labs := make([]Lab, 0, w*h)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			labs = append(labs, ToLab(src.At(x, y)))
+		}
+	}
+	if len(labs) == 0 {
+		return &KMeansResult{Quantized: image.NewNRGBA(bounds)}, nil
+	}
+
+	// Step 2: fit the centres on a random sample.
+	samples := labs
+	if len(labs) > kmeansMaxSamples {
+		samples = make([]Lab, kmeansMaxSamples)
+		for i := range samples {
+			samples[i] = labs[rng.Intn(len(labs))]
+		}
+	}
+	centres := kmeansPlusPlus(samples, k, rng)
+	if err := kmeansFit(ctx, samples, centres); err != nil {
+		return nil, err
+	}
+
+	// Step 3: label every pixel and collect cluster colours.
+	labels := make([]int, len(labs))
+	sums := make([][4]int, len(centres))
+	for i, l := range labs {
+		c := nearestCentre(l, centres)
+		labels[i] = c
+		r, g, b, _ := src.At(bounds.Min.X+i%w, bounds.Min.Y+i/w).RGBA()
+		sums[c][0] += int(r >> 8)
+		sums[c][1] += int(g >> 8)
+		sums[c][2] += int(b >> 8)
+		sums[c][3]++
+	}
+
+	res := &KMeansResult{Clusters: make([]Cluster, len(centres))}
+	for c, s := range sums {
+		if s[3] > 0 {
+			res.Clusters[c].Color = color.NRGBA{
+				R: uint8(s[0] / s[3]), G: uint8(s[1] / s[3]), B: uint8(s[2] / s[3]), A: 255,
+			}
+		}
+	}
+
+	// Step 4: build the quantized image, the masks and the contours.
+	quant := image.NewNRGBA(bounds)
+	for i, c := range labels {
+		quant.SetNRGBA(bounds.Min.X+i%w, bounds.Min.Y+i/w, res.Clusters[c].Color)
+	}
+	res.Quantized = quant
+
+	for c := range res.Clusters {
+		mask, err := maskWhere(ctx, bounds, func(x, y int) bool {
+			return labels[(y-bounds.Min.Y)*w+(x-bounds.Min.X)] == c
+		})
+		if err != nil {
+			return nil, err
+		}
+		contours, err := FindContours(ctx, mask)
+		if err != nil {
+			return nil, err
+		}
+		res.Clusters[c].Mask = mask
+		res.Clusters[c].Contours = contours
+	}
+	return res, ctx.Err()
+}
+
+// kmeansPlusPlus picks k initial centres, each new one with probability
+// proportional to its squared distance from the nearest chosen centre.
+func kmeansPlusPlus(samples []Lab, k int, rng *rand.Rand) []Lab {
+	centres := []Lab{samples[rng.Intn(len(samples))]}
+	dist := make([]float64, len(samples))
+	for len(centres) < k {
+		var total float64
+		for i, s := range samples {
+			d := s.Distance(centres[nearestCentre(s, centres)])
+			dist[i] = d * d
+			total += dist[i]
+		}
+		if total == 0 { // fewer distinct colours than clusters
+			break
+		}
+		r := rng.Float64() * total
+		i := 0
+		for ; i < len(dist)-1 && r >= dist[i]; i++ {
+			r -= dist[i]
+		}
+		centres = append(centres, samples[i])
+	}
+	return centres
+}
+
+// kmeansFit runs Lloyd iterations on centres until they stop moving.
+func kmeansFit(ctx context.Context, samples []Lab, centres []Lab) error {
+	sums := make([]Lab, len(centres))
+	counts := make([]int, len(centres))
+	for iter := 0; iter < kmeansMaxIter; iter++ {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		for i := range sums {
+			sums[i], counts[i] = Lab{}, 0
+		}
+		for _, s := range samples {
+			c := nearestCentre(s, centres)
+			sums[c].L += s.L
+			sums[c].A += s.A
+			sums[c].B += s.B
+			counts[c]++
+		}
+		var moved float64
+		for i := range centres {
+			if counts[i] == 0 {
+				continue
+			}
+			n := float64(counts[i])
+			next := Lab{L: sums[i].L / n, A: sums[i].A / n, B: sums[i].B / n}
+			moved = math.Max(moved, next.Distance(centres[i]))
+			centres[i] = next
+		}
+		if moved < 0.01 {
+			break
+		}
+	}
+	return nil
+}
+
+func nearestCentre(l Lab, centres []Lab) int {
+	best, bestD := 0, math.Inf(1)
+	for i, c := range centres {
+		dl, da, db := l.L-c.L, l.A-c.A, l.B-c.B
+		if d := dl*dl + da*da + db*db; d < bestD {
+			best, bestD = i, d
+		}
+	}
+	return best
+}
+
+// DrawClusterContours draws the contours of every cluster in the cluster's
+// own colour on a white background.
+func DrawClusterContours(ctx context.Context, bounds image.Rectangle, clusters []Cluster) (image.Image, error) {
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+
+	for _, cl := range clusters {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, c := range cl.Contours {
+			for _, p := range c.Points {
+				if p.In(bounds) {
+					dst.Set(p.X, p.Y, cl.Color)
+				}
+			}
+		}
+	}
+	return dst, ctx.Err()
+}
@@ -0,0 +1,51 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestKMeansSegment(t *testing.T) {
+	// three vertical stripes: red, green, blue
+	img := image.NewNRGBA(image.Rect(0, 0, 30, 10))
+	stripes := []color.NRGBA{{R: 255, A: 255}, {G: 255, A: 255}, {B: 255, A: 255}}
+	for y := 0; y < 10; y++ {
+		for x := 0; x < 30; x++ {
+			img.SetNRGBA(x, y, stripes[x/10])
+		}
+	}
+
+	ctx := context.Background()
+	res, err := KMeansSegment(ctx, img, 3, 1)
+	if err != nil {
+		t.Fatalf("got error while clustering image:\n%s", err.Error())
+	}
+	if len(res.Clusters) != 3 {
+		t.Fatalf("got %d clusters, want 3", len(res.Clusters))
+	}
+	seen := map[color.NRGBA]bool{}
+	for _, cl := range res.Clusters {
+		seen[cl.Color] = true
+		if len(cl.Contours) != 1 || cl.Contours[0].Hole {
+			t.Fatalf("cluster %v: got %d contours, want one outer contour", cl.Color, len(cl.Contours))
+		}
+	}
+	for _, s := range stripes {
+		if !seen[s] {
+			t.Fatalf("stripe colour %v not found among clusters", s)
+		}
+	}
+
+	// deterministic for a fixed seed
+	again, err := KMeansSegment(ctx, img, 3, 1)
+	if err != nil {
+		t.Fatalf("got error while clustering image:\n%s", err.Error())
+	}
+	for i := range res.Clusters {
+		if res.Clusters[i].Color != again.Clusters[i].Color {
+			t.Fatalf("cluster %d differs between runs: %v vs %v", i, res.Clusters[i].Color, again.Clusters[i].Color)
+		}
+	}
+}
@@ -0,0 +1,61 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+
+	"golang.org/x/image/font"
+	"golang.org/x/image/font/basicfont"
+	"golang.org/x/image/math/fixed"
+)
+
+// -----------------------------------------------------------------------------
+// Text labels
+// -----------------------------------------------------------------------------
+
+// TextLabel - text drawn centred on a point by DrawLabels.
+type TextLabel struct {
+	At   image.Point
+	Text string
+}
+
+// labelFace - built-in bitmap font of the labels.
+var labelFace = basicfont.Face7x13
+
+// DrawLabels returns a copy of src with each label written in col on a
+// white box, so it stays readable on top of contours.
+func DrawLabels(ctx context.Context, src image.Image, labels []TextLabel, col color.Color) (image.Image, error) {
+	bounds := src.Bounds()
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, src, bounds.Min, draw.Src)
+
+	d := &font.Drawer{Dst: out, Src: image.NewUniform(col), Face: labelFace}
+	h := labelFace.Height
+	for _, l := range labels {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		w := d.MeasureString(l.Text).Ceil()
+		box := image.Rect(l.At.X-w/2-2, l.At.Y-h/2-1, l.At.X-w/2+w+2, l.At.Y-h/2+h+1)
+		draw.Draw(out, box, image.White, image.Point{}, draw.Src)
+		d.Dot = fixed.P(box.Min.X+2, box.Min.Y+1+labelFace.Ascent)
+		d.DrawString(l.Text)
+	}
+	return out, nil
+}
+
+// labelBelow returns where to centre a label next to the object with
+// bounding box r: under it, or above it when that would leave bounds.
+func labelBelow(r, bounds image.Rectangle) image.Point {
+	x := (r.Min.X + r.Max.X) / 2
+	gap := labelFace.Height/2 + 3
+	if r.Max.Y+2*gap <= bounds.Max.Y {
+		return image.Pt(x, r.Max.Y+gap)
+	}
+	if r.Min.Y-2*gap >= bounds.Min.Y {
+		return image.Pt(x, r.Min.Y-gap)
+	}
+	return image.Pt(x, (r.Min.Y+r.Max.Y)/2)
+}
@@ -0,0 +1,296 @@
+package imageutil
+
+import (
+	"archive/zip"
+	"bytes"
+	"compress/zlib"
+	"context"
+	"encoding/binary"
+	"encoding/xml"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"io"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Layered output
+// -----------------------------------------------------------------------------
+
+// Layer - one named stage of the pipeline.
+type Layer struct {
+	Name   string
+	Image  image.Image
+	Hidden bool // start switched off in layer-aware editors
+}
+
+// StageLayers returns the pipeline stages of src as layers, bottom first:
+// the original, the binary image bin (hidden), the contours drawn with
+// style on a transparent layer, and the filled object mask (hidden).
+func StageLayers(ctx context.Context, src, bin image.Image, style OverlayStyle) ([]Layer, error) {
+	contours, err := FindContours(ctx, bin)
+	if err != nil {
+		return nil, err
+	}
+	lines, err := DrawOverlay(ctx, image.NewNRGBA(bin.Bounds()), contours, style)
+	if err != nil {
+		return nil, err
+	}
+	mask, err := ContourMask(ctx, bin.Bounds(), contours, 0)
+	if err != nil {
+		return nil, err
+	}
+	return []Layer{
+		{Name: "Original", Image: src},
+		{Name: "Binarized", Image: bin, Hidden: true},
+		{Name: "Contours", Image: lines},
+		{Name: "Mask", Image: MaskImage(mask), Hidden: true},
+	}, nil
+}
+
+// nrgba returns img as non-premultiplied 8-bit RGBA starting at (0, 0).
+func nrgba(img image.Image) *image.NRGBA {
+	b := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, b.Dx(), b.Dy()))
+	draw.Draw(out, out.Bounds(), img, b.Min, draw.Src)
+	return out
+}
+
+// TIFF field types used by WriteMultiPageTIFF.
+const (
+	tiffASCII    = 2
+	tiffShort    = 3
+	tiffLong     = 4
+	tiffRational = 5
+)
+
+// tiffEntry - one IFD entry; data longer than four bytes is stored after
+// the IFD.
+type tiffEntry struct {
+	tag, typ uint16
+	count    uint32
+	data     []byte
+}
+
+// WriteMultiPageTIFF writes every layer as a page of a little-endian TIFF:
+// deflate-compressed 8-bit RGBA with unassociated alpha, named by the
+// PageName tag.
+func WriteMultiPageTIFF(w io.Writer, layers []Layer) error {
+	if len(layers) == 0 {
+		return fmt.Errorf("TIFF needs at least one layer")
+	}
+	le := binary.LittleEndian
+	buf := []byte{'I', 'I', 42, 0, 0, 0, 0, 0}
+	next := 4 // where the offset of the next IFD goes
+
+	short := func(vs ...uint16) []byte {
+		b := make([]byte, 2*len(vs))
+		for i, v := range vs {
+			le.PutUint16(b[2*i:], v)
+		}
+		return b
+	}
+	long := func(v uint32) []byte { return le.AppendUint32(nil, v) }
+	rational := func(n, d uint32) []byte { return le.AppendUint32(le.AppendUint32(nil, n), d) }
+
+	for page, l := range layers {
+		img := nrgba(l.Image)
+		b := img.Bounds()
+
+		var strip bytes.Buffer
+		zw := zlib.NewWriter(&strip)
+		for y := 0; y < b.Dy(); y++ {
+			zw.Write(img.Pix[y*img.Stride : y*img.Stride+4*b.Dx()])
+		}
+		if err := zw.Close(); err != nil {
+			return err
+		}
+		stripOffset := len(buf)
+		buf = append(buf, strip.Bytes()...)
+		if len(buf)%2 == 1 {
+			buf = append(buf, 0) // IFDs start on a word boundary
+		}
+
+		name := append([]byte(l.Name), 0)
+		entries := []tiffEntry{
+			{254, tiffLong, 1, long(2)}, // NewSubfileType: page of a multi-page image
+			{256, tiffLong, 1, long(uint32(b.Dx()))},
+			{257, tiffLong, 1, long(uint32(b.Dy()))},
+			{258, tiffShort, 4, short(8, 8, 8, 8)},
+			{259, tiffShort, 1, short(8)}, // Adobe deflate
+			{262, tiffShort, 1, short(2)}, // RGB
+			{273, tiffLong, 1, long(uint32(stripOffset))},
+			{277, tiffShort, 1, short(4)},
+			{278, tiffLong, 1, long(uint32(b.Dy()))},
+			{279, tiffLong, 1, long(uint32(strip.Len()))},
+			{282, tiffRational, 1, rational(72, 1)},
+			{283, tiffRational, 1, rational(72, 1)},
+			{284, tiffShort, 1, short(1)},
+			{285, tiffASCII, uint32(len(name)), name},
+			{296, tiffShort, 1, short(2)}, // inches
+			{297, tiffShort, 2, short(uint16(page), uint16(len(layers)))},
+			{338, tiffShort, 1, short(2)}, // unassociated alpha
+		}
+
+		ifd := len(buf)
+		le.PutUint32(buf[next:], uint32(ifd))
+		extra := ifd + 2 + 12*len(entries) + 4
+		buf = le.AppendUint16(buf, uint16(len(entries)))
+		var values []byte
+		for _, e := range entries {
+			buf = le.AppendUint16(buf, e.tag)
+			buf = le.AppendUint16(buf, e.typ)
+			buf = le.AppendUint32(buf, e.count)
+			if len(e.data) <= 4 {
+				buf = append(buf, e.data...)
+				buf = append(buf, make([]byte, 4-len(e.data))...)
+				continue
+			}
+			buf = le.AppendUint32(buf, uint32(extra+len(values)))
+			values = append(values, e.data...)
+			if len(values)%2 == 1 {
+				values = append(values, 0)
+			}
+		}
+		next = len(buf)
+		buf = append(buf, 0, 0, 0, 0) // no next IFD yet
+		buf = append(buf, values...)
+	}
+	_, err := w.Write(buf)
+	return err
+}
+
+// oraThumbnailSize - longest side of the OpenRaster thumbnail.
+const oraThumbnailSize = 256
+
+// oraImage - root of the OpenRaster stack.xml.
+type oraImage struct {
+	XMLName xml.Name `xml:"image"`
+	Version string   `xml:"version,attr"`
+	W       int      `xml:"w,attr"`
+	H       int      `xml:"h,attr"`
+	Stack   struct {
+		Layers []oraLayer `xml:"layer"`
+	} `xml:"stack"`
+}
+
+// oraLayer - one layer of stack.xml.
+type oraLayer struct {
+	Name       string `xml:"name,attr"`
+	Src        string `xml:"src,attr"`
+	X          int    `xml:"x,attr"`
+	Y          int    `xml:"y,attr"`
+	Visibility string `xml:"visibility,attr"`
+}
+
+// WriteORA writes the layers as an OpenRaster file, the first layer at the
+// bottom of the stack, with the merged image and thumbnail the format
+// requires. Hidden layers are stored but left out of the merged image.
+func WriteORA(w io.Writer, layers []Layer) error {
+	if len(layers) == 0 {
+		return fmt.Errorf("OpenRaster needs at least one layer")
+	}
+	bounds := layers[0].Image.Bounds()
+	zw := zip.NewWriter(w)
+
+	// the mimetype comes first and uncompressed, so it can be sniffed
+	mt, err := zw.CreateHeader(&zip.FileHeader{Name: "mimetype", Method: zip.Store})
+	if err != nil {
+		return err
+	}
+	io.WriteString(mt, "image/openraster")
+
+	writePNG := func(name string, img image.Image) error {
+		f, err := zw.Create(name)
+		if err != nil {
+			return err
+		}
+		return png.Encode(f, img)
+	}
+
+	var stack []oraLayer
+	merged := image.NewNRGBA(image.Rect(0, 0, bounds.Dx(), bounds.Dy()))
+	for i, l := range layers {
+		src := fmt.Sprintf("data/%03d-%s.png", i, layerFileName(l.Name))
+		if err := writePNG(src, nrgba(l.Image)); err != nil {
+			return err
+		}
+		visibility := "visible"
+		if l.Hidden {
+			visibility = "hidden"
+		} else {
+			draw.Draw(merged, merged.Bounds(), l.Image, l.Image.Bounds().Min, draw.Over)
+		}
+		// stack.xml lists the top layer first
+		stack = append([]oraLayer{{l.Name, src, 0, 0, visibility}}, stack...)
+	}
+
+	f, err := zw.Create("stack.xml")
+	if err != nil {
+		return err
+	}
+	io.WriteString(f, xml.Header)
+	enc := xml.NewEncoder(f)
+	enc.Indent("", "  ")
+	doc := oraImage{Version: "0.0.5", W: bounds.Dx(), H: bounds.Dy()}
+	doc.Stack.Layers = stack
+	if err := enc.Encode(doc); err != nil {
+		return err
+	}
+
+	if err := writePNG("mergedimage.png", merged); err != nil {
+		return err
+	}
+	k := min(1, float64(oraThumbnailSize)/float64(max(bounds.Dx(), bounds.Dy())))
+	thumb := boxShrink(merged, max(1, int(float64(bounds.Dx())*k)), max(1, int(float64(bounds.Dy())*k)))
+	if err := writePNG("Thumbnails/thumbnail.png", thumb); err != nil {
+		return err
+	}
+	return zw.Close()
+}
+
+// boxShrink scales img down to w x h, averaging the pixels under each
+// output pixel weighted by their alpha.
+func boxShrink(img *image.NRGBA, w, h int) *image.NRGBA {
+	b := img.Bounds()
+	out := image.NewNRGBA(image.Rect(0, 0, w, h))
+	for y := range h {
+		y0, y1 := b.Min.Y+y*b.Dy()/h, b.Min.Y+max((y+1)*b.Dy()/h, y*b.Dy()/h+1)
+		for x := range w {
+			x0, x1 := b.Min.X+x*b.Dx()/w, b.Min.X+max((x+1)*b.Dx()/w, x*b.Dx()/w+1)
+			var r, g, bl, a, n int
+			for sy := y0; sy < y1; sy++ {
+				for sx := x0; sx < x1; sx++ {
+					p := img.Pix[img.PixOffset(sx, sy):]
+					pa := int(p[3])
+					r += int(p[0]) * pa
+					g += int(p[1]) * pa
+					bl += int(p[2]) * pa
+					a += pa
+					n++
+				}
+			}
+			o := out.Pix[out.PixOffset(x, y):]
+			if a > 0 {
+				o[0], o[1], o[2] = uint8(r/a), uint8(g/a), uint8(bl/a)
+			}
+			o[3] = uint8(a / n)
+		}
+	}
+	return out
+}
+
+// layerFileName turns a layer name into a safe file name.
+func layerFileName(name string) string {
+	return strings.Map(func(r rune) rune {
+		switch {
+		case r >= 'a' && r <= 'z', r >= '0' && r <= '9', r == '-':
+			return r
+		case r >= 'A' && r <= 'Z':
+			return r - 'A' + 'a'
+		}
+		return '_'
+	}, name)
+}
@@ -0,0 +1,143 @@
+package imageutil
+
+import (
+	"archive/zip"
+	"bytes"
+	"encoding/binary"
+	"encoding/xml"
+	"image"
+	"image/color"
+	"image/png"
+	"io"
+	"testing"
+
+	"golang.org/x/image/tiff"
+)
+
+// testLayers returns three 40x30 layers of different solid colours; the
+// middle one half transparent and hidden.
+func testLayers() []Layer {
+	cols := []color.NRGBA{{200, 10, 10, 255}, {10, 200, 10, 128}, {10, 10, 200, 255}}
+	names := []string{"Original", "Binarized", "Contours"}
+	layers := make([]Layer, len(cols))
+	for i, c := range cols {
+		img := image.NewNRGBA(image.Rect(0, 0, 40, 30))
+		for p := 0; p < len(img.Pix); p += 4 {
+			img.Pix[p], img.Pix[p+1], img.Pix[p+2], img.Pix[p+3] = c.R, c.G, c.B, c.A
+		}
+		layers[i] = Layer{Name: names[i], Image: img, Hidden: i == 1}
+	}
+	return layers
+}
+
+func TestWriteMultiPageTIFF(t *testing.T) {
+	layers := testLayers()
+	var buf bytes.Buffer
+	if err := WriteMultiPageTIFF(&buf, layers); err != nil {
+		t.Fatalf("got error while writing TIFF:\n%s", err.Error())
+	}
+
+	// the decoder reads the first page only: point the header at each
+	// page in turn
+	data := buf.Bytes()
+	le := binary.LittleEndian
+	ifd := le.Uint32(data[4:])
+	pages := 0
+	for ifd != 0 {
+		if pages == len(layers) {
+			t.Fatalf("got more than %d pages", len(layers))
+		}
+		page := append([]byte(nil), data...)
+		le.PutUint32(page[4:], ifd)
+		img, err := tiff.Decode(bytes.NewReader(page))
+		if err != nil {
+			t.Fatalf("got error while decoding page %d:\n%s", pages, err.Error())
+		}
+		want := layers[pages].Image.At(5, 5)
+		if got := color.NRGBAModel.Convert(img.At(5, 5)); got != want {
+			t.Errorf("got %v on page %d, want %v", got, pages, want)
+		}
+		n := le.Uint16(data[ifd:])
+		ifd = le.Uint32(data[ifd+2+12*uint32(n):])
+		pages++
+	}
+	if pages != len(layers) {
+		t.Errorf("got %d pages, want %d", pages, len(layers))
+	}
+	if err := WriteMultiPageTIFF(&buf, nil); err == nil {
+		t.Errorf("got no error for a TIFF without layers")
+	}
+}
+
+func TestWriteORA(t *testing.T) {
+	layers := testLayers()
+	var buf bytes.Buffer
+	if err := WriteORA(&buf, layers); err != nil {
+		t.Fatalf("got error while writing OpenRaster:\n%s", err.Error())
+	}
+	zr, err := zip.NewReader(bytes.NewReader(buf.Bytes()), int64(buf.Len()))
+	if err != nil {
+		t.Fatalf("got error while opening zip:\n%s", err.Error())
+	}
+	if f := zr.File[0]; f.Name != "mimetype" || f.Method != zip.Store {
+		t.Fatalf("got first entry %q (method %d), want a stored mimetype", f.Name, f.Method)
+	}
+	read := func(name string) []byte {
+		f, err := zr.Open(name)
+		if err != nil {
+			t.Fatalf("got error while opening %s:\n%s", name, err.Error())
+		}
+		defer f.Close()
+		b, err := io.ReadAll(f)
+		if err != nil {
+			t.Fatalf("got error while reading %s:\n%s", name, err.Error())
+		}
+		return b
+	}
+	if mt := string(read("mimetype")); mt != "image/openraster" {
+		t.Errorf("got mimetype %q", mt)
+	}
+
+	var doc oraImage
+	if err := xml.Unmarshal(read("stack.xml"), &doc); err != nil {
+		t.Fatalf("got error while parsing stack.xml:\n%s", err.Error())
+	}
+	if doc.W != 40 || doc.H != 30 || len(doc.Stack.Layers) != 3 {
+		t.Fatalf("got stack %+v", doc)
+	}
+	if top := doc.Stack.Layers[0]; top.Name != "Contours" || top.Visibility != "visible" {
+		t.Errorf("got top layer %+v, want the last layer, visible", top)
+	}
+	if mid := doc.Stack.Layers[1]; mid.Visibility != "hidden" {
+		t.Errorf("got middle layer %+v, want hidden", mid)
+	}
+	for _, l := range doc.Stack.Layers {
+		if _, err := png.Decode(bytes.NewReader(read(l.Src))); err != nil {
+			t.Errorf("got error while decoding layer %s:\n%s", l.Name, err.Error())
+		}
+	}
+	for _, name := range []string{"mergedimage.png", "Thumbnails/thumbnail.png"} {
+		if _, err := png.Decode(bytes.NewReader(read(name))); err != nil {
+			t.Errorf("got error while decoding %s:\n%s", name, err.Error())
+		}
+	}
+}
+
+func TestBoxShrink(t *testing.T) {
+	// 4x2 halving to 2x1: red and blue average to purple, a transparent
+	// pixel leaves the colour of its opaque neighbours at 3/4 opacity
+	img := image.NewNRGBA(image.Rect(0, 0, 4, 2))
+	for y := range 2 {
+		img.SetNRGBA(0, y, color.NRGBA{R: 200, A: 255})
+		img.SetNRGBA(1, y, color.NRGBA{B: 200, A: 255})
+		img.SetNRGBA(2, y, color.NRGBA{G: 200, A: 255})
+	}
+	img.SetNRGBA(3, 1, color.NRGBA{G: 200, A: 255})
+	out := boxShrink(img, 2, 1)
+	if got, want := out.NRGBAAt(0, 0), (color.NRGBA{R: 100, B: 100, A: 255}); got != want {
+		t.Errorf("got left pixel %v, want %v", got, want)
+	}
+	if got, want := out.NRGBAAt(1, 0), (color.NRGBA{G: 200, A: 191}); got != want {
+		t.Errorf("got right pixel %v, want %v", got, want)
+	}
+}
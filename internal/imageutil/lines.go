@@ -0,0 +1,63 @@
+package imageutil
+
+import (
+	"image"
+	"image/color"
+	"image/draw"
+)
+
+// drawLine plots the pixels of the segment a-b (Bresenham), clipped to dst.
+func drawLine(dst draw.Image, a, b image.Point, col color.Color) {
+	bounds := dst.Bounds()
+	dx, dy := abs(b.X-a.X), -abs(b.Y-a.Y)
+	sx, sy := 1, 1
+	if a.X > b.X {
+		sx = -1
+	}
+	if a.Y > b.Y {
+		sy = -1
+	}
+	e := dx + dy
+	for p := a; ; {
+		if p.In(bounds) {
+			dst.Set(p.X, p.Y, col)
+		}
+		if p == b {
+			return
+		}
+		e2 := 2 * e
+		if e2 >= dy {
+			e += dy
+			p.X += sx
+		}
+		if e2 <= dx {
+			e += dx
+			p.Y += sy
+		}
+	}
+}
+
+// drawRing plots the closed polygon pts.
+func drawRing(dst draw.Image, pts []PointF, col color.Color) {
+	for i := range pts {
+		drawLine(dst, pts[i].Round(), pts[(i+1)%len(pts)].Round(), col)
+	}
+}
+
+// drawDashed plots the segment a-b as dashes of the given length in pixels.
+func drawDashed(dst draw.Image, a, b image.Point, col color.Color, dash int) {
+	pa, pb := Pf(a), Pf(b)
+	n := max(abs(b.X-a.X), abs(b.Y-a.Y))
+	for i := 0; i <= n; i++ {
+		if (i/dash)%2 == 1 {
+			continue
+		}
+		t := 0.0
+		if n > 0 {
+			t = float64(i) / float64(n)
+		}
+		if p := pa.Lerp(pb, t).Round(); p.In(dst.Bounds()) {
+			dst.Set(p.X, p.Y, col)
+		}
+	}
+}
@@ -0,0 +1,161 @@
+package imageutil
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"sort"
+)
+
+// -----------------------------------------------------------------------------
+// Shape matching
+// -----------------------------------------------------------------------------
+
+// DefaultMatchThreshold - minimum similarity of a contour to count as a match.
+const DefaultMatchThreshold = 0.8
+
+const huEpsilon = 1e-5 // Hu invariants below this are treated as noise, as in OpenCV
+
+// ShapeSignature - description of a contour that does not change under
+// translation, scaling and rotation.
+type ShapeSignature struct {
+	Hu      [7]float64 // Hu moment invariants
+	Fourier []float64  // normalised Fourier descriptor magnitudes
+}
+
+// NewShapeSignature computes the signature of a closed contour.
+func NewShapeSignature(points []image.Point) ShapeSignature {
+	pts := toPointsF(points)
+	// moments about the mean point keep the third-order terms small
+	var mean PointF
+	for _, p := range pts {
+		mean = mean.Add(p)
+	}
+	mean = mean.Mul(1 / float64(len(pts)))
+	centred := make([]PointF, len(pts))
+	for i, p := range pts {
+		centred[i] = p.Sub(mean)
+	}
+	return ShapeSignature{
+		Hu:      PolygonMoments(centred).Hu(),
+		Fourier: FourierDescriptors(centred, fourierHarmonics),
+	}
+}
+
+// HuDistance compares the log-scaled Hu invariants of two signatures
+// (the I2 measure of OpenCV's matchShapes).
+func (s ShapeSignature) HuDistance(o ShapeSignature) float64 {
+	var d float64
+	for i := range s.Hu {
+		a, b := s.Hu[i], o.Hu[i]
+		if math.Abs(a) < huEpsilon || math.Abs(b) < huEpsilon {
+			continue
+		}
+		la := math.Copysign(math.Log10(math.Abs(a)), a)
+		lb := math.Copysign(math.Log10(math.Abs(b)), b)
+		d += math.Abs(la - lb)
+	}
+	return d
+}
+
+// FourierDistance returns the Euclidean distance between the Fourier
+// descriptors of two signatures.
+func (s ShapeSignature) FourierDistance(o ShapeSignature) float64 {
+	var d float64
+	for i := range min(len(s.Fourier), len(o.Fourier)) {
+		v := s.Fourier[i] - o.Fourier[i]
+		d += v * v
+	}
+	return math.Sqrt(d)
+}
+
+// Similarity combines both distances into a score in (0, 1]; 1 means the
+// shapes are identical up to position, size and rotation.
+func (s ShapeSignature) Similarity(o ShapeSignature) float64 {
+	return 1 / (1 + s.HuDistance(o) + 5*s.FourierDistance(o))
+}
+
+// Match - similarity of one contour to the template.
+type Match struct {
+	Contour Contour
+	Hu      float64 // Hu moment distance
+	Fourier float64 // Fourier descriptor distance
+	Score   float64 // combined similarity, see ShapeSignature.Similarity
+}
+
+// MatchShapes scores every outer contour against the template contour and
+// returns the results best first. Contours with fewer than minPoints
+// points are skipped as noise.
+func MatchShapes(ctx context.Context, template []image.Point, contours []Contour, minPoints int) ([]Match, error) {
+	ref := NewShapeSignature(template)
+	var matches []Match
+	for _, c := range contours {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		if c.Hole || len(c.Points) < max(minPoints, 3) {
+			continue
+		}
+		sig := NewShapeSignature(c.Points)
+		matches = append(matches, Match{
+			Contour: c,
+			Hu:      ref.HuDistance(sig),
+			Fourier: ref.FourierDistance(sig),
+			Score:   ref.Similarity(sig),
+		})
+	}
+	sort.SliceStable(matches, func(i, j int) bool { return matches[i].Score > matches[j].Score })
+	return matches, nil
+}
+
+// LargestContour returns the outer contour enclosing the largest area.
+func LargestContour(contours []Contour) (Contour, bool) {
+	var best Contour
+	bestArea, found := -1.0, false
+	for _, c := range contours {
+		if c.Hole {
+			continue
+		}
+		if a := PolygonArea(toPointsF(c.Points)); a > bestArea {
+			best, bestArea, found = c, a, true
+		}
+	}
+	return best, found
+}
+
+// DrawMatches draws the scored contours over a copy of src: matches with a
+// score of at least threshold in green with their score, the rest in grey.
+func DrawMatches(ctx context.Context, src image.Image, matches []Match, threshold float64) (image.Image, error) {
+	bounds := src.Bounds()
+	out := image.NewRGBA(bounds)
+	draw.Draw(out, bounds, src, bounds.Min, draw.Src)
+
+	hit := color.RGBA{G: 200, A: 255}
+	miss := color.RGBA{R: 160, G: 160, B: 160, A: 255}
+	var labels []TextLabel
+	for _, m := range matches {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		col := miss
+		if m.Score >= threshold {
+			col = hit
+			labels = append(labels, TextLabel{
+				At:   labelBelow(pointsBounds(m.Contour.Points), bounds),
+				Text: fmt.Sprintf("%.2f", m.Score),
+			})
+		}
+		for _, p := range m.Contour.Points {
+			// two pixels wide so matches stand out on the photo
+			for _, q := range []image.Point{p, p.Add(image.Pt(1, 0)), p.Add(image.Pt(0, 1))} {
+				if q.In(bounds) {
+					out.Set(q.X, q.Y, col)
+				}
+			}
+		}
+	}
+	return DrawLabels(ctx, out, labels, hit)
+}
@@ -0,0 +1,49 @@
+package imageutil
+
+import (
+	"context"
+	"testing"
+)
+
+// shapeContour returns the largest contour of the shape painted by inside.
+func shapeContour(t *testing.T, inside func(PointF) bool) Contour {
+	contours, err := FindContours(context.Background(), filledShape(inside))
+	if err != nil {
+		t.Fatalf("got error while finding contours:\n%s", err.Error())
+	}
+	c, ok := LargestContour(contours)
+	if !ok {
+		t.Fatalf("no contour found")
+	}
+	return c
+}
+
+func TestMatchShapes(t *testing.T) {
+	star := shapeContour(t, polygonShape(regularPolygon(10, 80, 32, 0)))
+	candidates := []Contour{
+		shapeContour(t, polygonShape(regularPolygon(10, 40, 16, 0.5))), // smaller, rotated star
+		shapeContour(t, polygonShape(regularPolygon(12, 80, 32, 0))),   // six points
+		shapeContour(t, polygonShape(regularPolygon(6, 60, 60, 0.2))),
+		shapeContour(t, func(p PointF) bool { return p.Dist(PointF{100, 100}) < 50 }),
+	}
+	for i := range candidates {
+		candidates[i].ID = i + 1
+	}
+
+	matches, err := MatchShapes(context.Background(), star.Points, candidates, 0)
+	if err != nil {
+		t.Fatalf("got error while matching shapes:\n%s", err.Error())
+	}
+	if len(matches) != len(candidates) {
+		t.Fatalf("got %d matches, want %d", len(matches), len(candidates))
+	}
+	if matches[0].Contour.ID != 1 || matches[0].Score < DefaultMatchThreshold {
+		t.Fatalf("best match is contour %d with score %.2f, want the rotated star above %.2f",
+			matches[0].Contour.ID, matches[0].Score, DefaultMatchThreshold)
+	}
+	for _, m := range matches[1:] {
+		if m.Score >= DefaultMatchThreshold {
+			t.Fatalf("contour %d scored %.2f, want below %.2f", m.Contour.ID, m.Score, DefaultMatchThreshold)
+		}
+	}
+}
@@ -0,0 +1,103 @@
+package imageutil
+
+import (
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"image"
+	"io"
+	"math"
+	"strconv"
+)
+
+// -----------------------------------------------------------------------------
+// Contour metrics
+// -----------------------------------------------------------------------------
+
+// ContourMetrics - measurements of one contour.
+type ContourMetrics struct {
+	ID          int       `json:"id"`
+	Parent      int       `json:"parent"`
+	Hole        bool      `json:"hole"`
+	Points      int       `json:"points"`
+	Area        float64   `json:"area"`        // polygon area through the border pixels
+	Perimeter   float64   `json:"perimeter"`   // polygon length through the border pixels
+	Circularity float64   `json:"circularity"` // 4*pi*area / perimeter^2
+	X           int       `json:"x"`           // bounding box
+	Y           int       `json:"y"`
+	Width       int       `json:"width"`
+	Height      int       `json:"height"`
+	Shape       ShapeKind `json:"shape"`
+	Vertices    int       `json:"vertices"`
+}
+
+// Rect returns the bounding box of the contour.
+func (m ContourMetrics) Rect() image.Rectangle {
+	return image.Rect(m.X, m.Y, m.X+m.Width, m.Y+m.Height)
+}
+
+// MeasureContours computes the metrics and shape class of every contour.
+func MeasureContours(ctx context.Context, contours []Contour) ([]ContourMetrics, error) {
+	ms := make([]ContourMetrics, 0, len(contours))
+	for _, c := range contours {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		pts := toPointsF(c.Points)
+		m := ContourMetrics{
+			ID:        c.ID,
+			Parent:    c.Parent,
+			Hole:      c.Hole,
+			Points:    len(c.Points),
+			Area:      PolygonArea(pts),
+			Perimeter: Perimeter(pts),
+		}
+		r := pointsBounds(c.Points)
+		m.X, m.Y, m.Width, m.Height = r.Min.X, r.Min.Y, r.Dx(), r.Dy()
+		if m.Perimeter > 0 {
+			m.Circularity = 4 * math.Pi * m.Area / (m.Perimeter * m.Perimeter)
+		}
+		s := ClassifyShape(c.Points)
+		m.Shape, m.Vertices = s.Kind, s.Vertices
+		ms = append(ms, m)
+	}
+	return ms, nil
+}
+
+// ShapeLabels returns a shape label next to every object (not hole) of ms.
+func ShapeLabels(ms []ContourMetrics, bounds image.Rectangle) []TextLabel {
+	var labels []TextLabel
+	for _, m := range ms {
+		if m.Hole {
+			continue
+		}
+		s := Shape{Kind: m.Shape, Vertices: m.Vertices}
+		labels = append(labels, TextLabel{At: labelBelow(m.Rect(), bounds), Text: s.String()})
+	}
+	return labels
+}
+
+// WriteMetricsJSON writes the metrics as an indented JSON array.
+func WriteMetricsJSON(w io.Writer, ms []ContourMetrics) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(ms)
+}
+
+// WriteMetricsCSV writes the metrics as CSV with a header row.
+func WriteMetricsCSV(w io.Writer, ms []ContourMetrics) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"id", "parent", "hole", "points", "area", "perimeter", "circularity",
+		"x", "y", "width", "height", "shape", "vertices"})
+	ff := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	for _, m := range ms {
+		cw.Write([]string{
+			strconv.Itoa(m.ID), strconv.Itoa(m.Parent), strconv.FormatBool(m.Hole),
+			strconv.Itoa(m.Points), ff(m.Area), ff(m.Perimeter), ff(m.Circularity),
+			strconv.Itoa(m.X), strconv.Itoa(m.Y), strconv.Itoa(m.Width), strconv.Itoa(m.Height),
+			string(m.Shape), strconv.Itoa(m.Vertices),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,59 @@
+package imageutil
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestMeasureContours(t *testing.T) {
+	ctx := context.Background()
+	square := RotatedRect{Center: PointF{100, 100}, Axis: PointF{1, 0}, W: 60, H: 60}.Corners()
+	contours, err := FindContours(ctx, filledShape(polygonShape(square[:])))
+	if err != nil {
+		t.Fatalf("got error while finding contours:\n%s", err.Error())
+	}
+	ms, err := MeasureContours(ctx, contours)
+	if err != nil {
+		t.Fatalf("got error while measuring contours:\n%s", err.Error())
+	}
+	m := ms[0]
+	if m.Shape != ShapeSquare || m.Rect() != image.Rect(70, 70, 130, 130) || m.Area != 59*59 {
+		t.Fatalf("got %+v, want a 60x60 square at (70,70)", m)
+	}
+
+	var buf bytes.Buffer
+	if err := WriteMetricsCSV(&buf, ms); err != nil {
+		t.Fatalf("got error while writing CSV:\n%s", err.Error())
+	}
+	lines := strings.Split(strings.TrimSpace(buf.String()), "\n")
+	if len(lines) != 2 || !strings.HasSuffix(lines[1], ",70,70,60,60,square,4") {
+		t.Fatalf("unexpected CSV:\n%s", buf.String())
+	}
+
+	bounds := image.Rect(0, 0, 200, 200)
+	labels := ShapeLabels(ms, bounds)
+	if len(labels) != 1 || labels[0].Text != "square" || labels[0].At.Y <= 130 {
+		t.Fatalf("got labels %+v, want one square label below the object", labels)
+	}
+	out, err := DrawLabels(ctx, filledShape(func(PointF) bool { return false }), labels, color.Black)
+	if err != nil {
+		t.Fatalf("got error while drawing labels:\n%s", err.Error())
+	}
+	// some text pixels were drawn around the label position
+	dark := 0
+	at := labels[0].At
+	for y := at.Y - 8; y < at.Y+8; y++ {
+		for x := at.X - 25; x < at.X+25; x++ {
+			if color.GrayModel.Convert(out.At(x, y)).(color.Gray).Y < 128 {
+				dark++
+			}
+		}
+	}
+	if dark == 0 {
+		t.Fatalf("no label text drawn at %v", at)
+	}
+}
@@ -0,0 +1,123 @@
+package imageutil
+
+import (
+	"math"
+)
+
+// -----------------------------------------------------------------------------
+// Region moments
+// -----------------------------------------------------------------------------
+
+// Moments - raw area moments of a polygon region up to third order.
+type Moments struct {
+	M00, M10, M01, M20, M11, M02, M30, M21, M12, M03 float64
+}
+
+// PolygonMoments integrates the moments of the region enclosed by a closed
+// polygon (Green's theorem). The result does not depend on the point order.
+func PolygonMoments(pts []PointF) Moments {
+	m := Moments{
+		M00: polygonMoment(pts, 0, 0),
+		M10: polygonMoment(pts, 1, 0), M01: polygonMoment(pts, 0, 1),
+		M20: polygonMoment(pts, 2, 0), M11: polygonMoment(pts, 1, 1), M02: polygonMoment(pts, 0, 2),
+		M30: polygonMoment(pts, 3, 0), M21: polygonMoment(pts, 2, 1),
+		M12: polygonMoment(pts, 1, 2), M03: polygonMoment(pts, 0, 3),
+	}
+	if m.M00 < 0 {
+		m = Moments{-m.M00, -m.M10, -m.M01, -m.M20, -m.M11, -m.M02, -m.M30, -m.M21, -m.M12, -m.M03}
+	}
+	return m
+}
+
+// polygonMoment returns the signed moment m_pq of a polygon: the integral
+// of x^p * y^q over its area, expressed as a sum over its edges.
+func polygonMoment(pts []PointF, p, q int) float64 {
+	var sum float64
+	for i := range pts {
+		a, b := pts[i], pts[(i+1)%len(pts)]
+		var s float64
+		for k := 0; k <= p; k++ {
+			for l := 0; l <= q; l++ {
+				s += binomial(k+l, l) * binomial(p+q-k-l, q-l) *
+					math.Pow(a.X, float64(k)) * math.Pow(b.X, float64(p-k)) *
+					math.Pow(a.Y, float64(l)) * math.Pow(b.Y, float64(q-l))
+			}
+		}
+		sum += a.Cross(b) * s
+	}
+	n := p + q
+	return sum / (float64((n+2)*(n+1)) * binomial(n, p))
+}
+
+// binomial returns n choose k.
+func binomial(n, k int) float64 {
+	r := 1.0
+	for i := 1; i <= k; i++ {
+		r = r * float64(n-k+i) / float64(i)
+	}
+	return r
+}
+
+// Centroid returns the centre of mass of the region.
+func (m Moments) Centroid() PointF {
+	if m.M00 == 0 {
+		return PointF{}
+	}
+	return PointF{m.M10 / m.M00, m.M01 / m.M00}
+}
+
+// Covariance returns the central second moments divided by the area.
+func (m Moments) Covariance() (xx, xy, yy float64) {
+	if m.M00 == 0 {
+		return 0, 0, 0
+	}
+	c := m.Centroid()
+	return m.M20/m.M00 - c.X*c.X, m.M11/m.M00 - c.X*c.Y, m.M02/m.M00 - c.Y*c.Y
+}
+
+// Ellipse returns the ellipse with the same second moments as the region:
+// its semi-axes a >= b and the direction of the major axis in radians.
+func (m Moments) Ellipse() (a, b, theta float64) {
+	xx, xy, yy := m.Covariance()
+	mean, diff := (xx+yy)/2, math.Hypot((xx-yy)/2, xy)
+	// a uniform ellipse with semi-axis a has variance a^2 / 4 along it
+	a = 2 * math.Sqrt(math.Max(mean+diff, 0))
+	b = 2 * math.Sqrt(math.Max(mean-diff, 0))
+	theta = 0.5 * math.Atan2(2*xy, xx-yy)
+	return a, b, theta
+}
+
+// Hu returns the seven Hu moment invariants of the region, which do not
+// change under translation, scaling and rotation.
+func (m Moments) Hu() [7]float64 {
+	if m.M00 == 0 {
+		return [7]float64{}
+	}
+	c := m.Centroid()
+	x, y := c.X, c.Y
+
+	// central moments
+	mu20 := m.M20 - x*m.M10
+	mu11 := m.M11 - x*m.M01
+	mu02 := m.M02 - y*m.M01
+	mu30 := m.M30 - 3*x*m.M20 + 2*x*x*m.M10
+	mu21 := m.M21 - 2*x*m.M11 - y*m.M20 + 2*x*x*m.M01
+	mu12 := m.M12 - 2*y*m.M11 - x*m.M02 + 2*y*y*m.M10
+	mu03 := m.M03 - 3*y*m.M02 + 2*y*y*m.M01
+
+	// scale-normalised central moments
+	s2, s3 := m.M00*m.M00, math.Pow(m.M00, 2.5)
+	n20, n11, n02 := mu20/s2, mu11/s2, mu02/s2
+	n30, n21, n12, n03 := mu30/s3, mu21/s3, mu12/s3, mu03/s3
+
+	a, b := n30+n12, n21+n03
+	return [7]float64{
+		n20 + n02,
+		(n20-n02)*(n20-n02) + 4*n11*n11,
+		(n30-3*n12)*(n30-3*n12) + (3*n21-n03)*(3*n21-n03),
+		a*a + b*b,
+		(n30-3*n12)*a*(a*a-3*b*b) + (3*n21-n03)*b*(3*a*a-b*b),
+		(n20-n02)*(a*a-b*b) + 4*n11*a*b,
+		(3*n21-n03)*a*(a*a-3*b*b) - (n30-3*n12)*b*(3*a*a-b*b),
+	}
+}
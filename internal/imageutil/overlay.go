@@ -0,0 +1,253 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"image/draw"
+	"math"
+	"strconv"
+
+	"golang.org/x/image/vector"
+)
+
+// -----------------------------------------------------------------------------
+// Contour overlays
+// -----------------------------------------------------------------------------
+
+// OverlayStyle - how DrawOverlay draws the contours.
+type OverlayStyle struct {
+	Color     color.Color   // line colour when Palette is empty
+	Palette   []color.Color // colours cycled by object ID; holes take their object's
+	Thickness float64       // line width in pixels
+	Antialias bool          // smooth edges; false gives hard pixel edges
+	Opacity   float64       // of the lines, 0 to 1
+	Fill      float64       // opacity of the filled objects, 0 draws outlines only
+	Labels    bool          // write the object IDs next to the objects
+}
+
+// DefaultOverlayStyle - solid red two-pixel antialiased outlines.
+var DefaultOverlayStyle = OverlayStyle{
+	Color:     color.RGBA{R: 255, A: 255},
+	Thickness: 2,
+	Antialias: true,
+	Opacity:   1,
+}
+
+// OverlayPalette - ten distinct colours for per-object styles.
+var OverlayPalette = []color.Color{
+	color.RGBA{0x1f, 0x77, 0xb4, 0xff},
+	color.RGBA{0xff, 0x7f, 0x0e, 0xff},
+	color.RGBA{0x2c, 0xa0, 0x2c, 0xff},
+	color.RGBA{0xd6, 0x27, 0x28, 0xff},
+	color.RGBA{0x94, 0x67, 0xbd, 0xff},
+	color.RGBA{0x8c, 0x56, 0x4b, 0xff},
+	color.RGBA{0xe3, 0x77, 0xc2, 0xff},
+	color.RGBA{0x7f, 0x7f, 0x7f, 0xff},
+	color.RGBA{0xbc, 0xbd, 0x22, 0xff},
+	color.RGBA{0x17, 0xbe, 0xcf, 0xff},
+}
+
+// overlaySimplify - Douglas-Peucker tolerance of the drawn outlines; it
+// straightens the pixel staircase so antialiased lines look smooth.
+const overlaySimplify = 0.5
+
+// DrawOverlay returns a copy of base with the contours drawn on top:
+// optional filled objects (holes left open), then the outlines, then the
+// ID labels.
+func DrawOverlay(ctx context.Context, base image.Image, contours []Contour, style OverlayStyle) (image.Image, error) {
+	bounds := base.Bounds()
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, base, bounds.Min, draw.Src)
+
+	colorOf := func(c Contour) color.Color {
+		if len(style.Palette) == 0 {
+			return style.Color
+		}
+		id := c.ID
+		if c.Hole {
+			id = c.Parent
+		}
+		return style.Palette[(id-1)%len(style.Palette)]
+	}
+	rings := make([][]PointF, len(contours))
+	for i, c := range contours {
+		rings[i] = centred(c.Points)
+	}
+
+	if style.Fill > 0 {
+		holes := make(map[int][][]PointF)
+		for i, c := range contours {
+			if c.Hole {
+				holes[c.Parent] = append(holes[c.Parent], rings[i])
+			}
+		}
+		for i, c := range contours {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			if c.Hole {
+				continue
+			}
+			object := append([][]PointF{rings[i]}, holes[c.ID]...)
+			r := ringsBounds(object, 1).Intersect(bounds)
+			mask := fillRings(r, object, style.Antialias)
+			paintMask(dst, r, mask, colorOf(c), style.Fill)
+		}
+	}
+
+	if style.Thickness > 0 && style.Opacity > 0 {
+		half := style.Thickness / 2
+		for i, c := range contours {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+			ring := ApproxPolygon(rings[i], overlaySimplify)
+			r := ringsBounds([][]PointF{ring}, half+1).Intersect(bounds)
+			mask := strokeRing(r, ring, half, style.Antialias)
+			paintMask(dst, r, mask, colorOf(c), style.Opacity)
+		}
+	}
+
+	if !style.Labels {
+		return dst, ctx.Err()
+	}
+	var labels []TextLabel
+	for i, c := range contours {
+		if c.Hole {
+			continue
+		}
+		r := ringsBounds(rings[i:i+1], 0)
+		labels = append(labels, TextLabel{At: labelBelow(r, bounds), Text: strconv.Itoa(c.ID)})
+	}
+	return DrawLabels(ctx, dst, labels, color.Black)
+}
+
+// centred returns the pixel centres of pts.
+func centred(pts []image.Point) []PointF {
+	out := make([]PointF, len(pts))
+	for i, p := range pts {
+		out[i] = PointF{float64(p.X) + 0.5, float64(p.Y) + 0.5}
+	}
+	return out
+}
+
+// ringsBounds returns the pixels touched by the rings grown by margin.
+func ringsBounds(rings [][]PointF, margin float64) image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, ring := range rings {
+		for _, p := range ring {
+			minX, maxX = min(minX, p.X), max(maxX, p.X)
+			minY, maxY = min(minY, p.Y), max(maxY, p.Y)
+		}
+	}
+	if minX > maxX {
+		return image.Rectangle{}
+	}
+	return image.Rect(
+		int(math.Floor(minX-margin)), int(math.Floor(minY-margin)),
+		int(math.Ceil(maxX+margin)), int(math.Ceil(maxY+margin)),
+	)
+}
+
+// rasterize returns the coverage of the paths added by build, in a mask
+// covering r. The rasterizer sums the winding of overlapping paths, so
+// paths drawn in one direction merge and paths drawn in the other cut
+// them out. Without antialiasing the coverage is rounded to 0 or 255.
+func rasterize(r image.Rectangle, antialias bool, build func(z *vector.Rasterizer, at func(PointF) (float32, float32))) *image.Alpha {
+	mask := image.NewAlpha(image.Rect(0, 0, r.Dx(), r.Dy()))
+	if r.Empty() {
+		return mask
+	}
+	z := vector.NewRasterizer(r.Dx(), r.Dy())
+	at := func(p PointF) (float32, float32) {
+		return float32(p.X - float64(r.Min.X)), float32(p.Y - float64(r.Min.Y))
+	}
+	build(z, at)
+	z.DrawOp = draw.Src
+	z.Draw(mask, mask.Bounds(), image.Opaque, image.Point{})
+	if !antialias {
+		for i, a := range mask.Pix {
+			if a >= 128 {
+				mask.Pix[i] = 255
+			} else {
+				mask.Pix[i] = 0
+			}
+		}
+	}
+	return mask
+}
+
+// fillRings returns the coverage of the first ring minus the others.
+func fillRings(r image.Rectangle, rings [][]PointF, antialias bool) *image.Alpha {
+	return rasterize(r, antialias, func(z *vector.Rasterizer, at func(PointF) (float32, float32)) {
+		for i, ring := range rings {
+			if len(ring) < 3 {
+				continue
+			}
+			// the outer ring one way round, the holes the other
+			reverse := (SignedArea(ring) > 0) != (i == 0)
+			for j := range ring {
+				k := j
+				if reverse {
+					k = len(ring) - 1 - j
+				}
+				x, y := at(ring[k])
+				if j == 0 {
+					z.MoveTo(x, y)
+				} else {
+					z.LineTo(x, y)
+				}
+			}
+			z.ClosePath()
+		}
+	})
+}
+
+// joinSides - sides of the polygons approximating round line joins.
+const joinSides = 8
+
+// strokeRing returns the coverage of a closed line of half width half
+// along ring, built from one quad per edge and a round join per vertex.
+func strokeRing(r image.Rectangle, ring []PointF, half float64, antialias bool) *image.Alpha {
+	return rasterize(r, antialias, func(z *vector.Rasterizer, at func(PointF) (float32, float32)) {
+		poly := func(pts ...PointF) {
+			for j, p := range pts {
+				x, y := at(p)
+				if j == 0 {
+					z.MoveTo(x, y)
+				} else {
+					z.LineTo(x, y)
+				}
+			}
+			z.ClosePath()
+		}
+		join := make([]PointF, joinSides)
+		for i, a := range ring {
+			// every polygon runs the same way round, so they merge
+			for k := range join {
+				t := -2 * math.Pi * float64(k) / joinSides
+				join[k] = PointF{a.X + half*math.Cos(t), a.Y + half*math.Sin(t)}
+			}
+			poly(join...)
+
+			b := ring[(i+1)%len(ring)]
+			d := b.Sub(a)
+			l := d.Len()
+			if l == 0 {
+				continue
+			}
+			n := PointF{-d.Y, d.X}.Mul(half / l)
+			poly(a.Add(n), b.Add(n), b.Sub(n), a.Sub(n))
+		}
+	})
+}
+
+// paintMask blends col at the given opacity into dst through mask, which
+// covers r.
+func paintMask(dst draw.Image, r image.Rectangle, mask *image.Alpha, col color.Color, opacity float64) {
+	c := color.NRGBAModel.Convert(col).(color.NRGBA)
+	c.A = uint8(math.Round(float64(c.A) * min(max(opacity, 0), 1)))
+	draw.DrawMask(dst, r, image.NewUniform(c), image.Point{}, mask, image.Point{}, draw.Over)
+}
@@ -0,0 +1,80 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+)
+
+func TestDrawOverlay(t *testing.T) {
+	// ring with a dot inside its hole
+	mask := filledShape(func(p PointF) bool {
+		d := p.Dist(PointF{100, 100})
+		return (d < 70 && d > 40) || d < 15
+	})
+	contours, err := FindContours(context.Background(), mask)
+	if err != nil {
+		t.Fatalf("got error while finding contours:\n%s", err.Error())
+	}
+	rgba := func(img image.Image, x, y int) color.RGBA {
+		return color.RGBAModel.Convert(img.At(x, y)).(color.RGBA)
+	}
+
+	// hard edges: every pixel is either the base or the line colour
+	style := DefaultOverlayStyle
+	style.Antialias = false
+	out, err := DrawOverlay(context.Background(), mask, contours, style)
+	if err != nil {
+		t.Fatalf("got error while drawing overlay:\n%s", err.Error())
+	}
+	red := 0
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			switch c := rgba(out, x, y); c {
+			case color.RGBA{255, 0, 0, 255}:
+				red++
+			case color.RGBA{0, 0, 0, 255}, color.RGBA{255, 255, 255, 255}:
+			default:
+				t.Fatalf("got colour %v at (%d,%d) without antialiasing", c, x, y)
+			}
+		}
+	}
+	if red == 0 {
+		t.Fatalf("got no line pixels")
+	}
+	if c := rgba(out, 169, 100); c.G != 0 || c.R != 255 {
+		t.Errorf("got %v on the outer border, want red", c)
+	}
+	if c := rgba(out, 155, 100); c.R != 0 {
+		t.Errorf("got %v inside the ring without fill, want the base", c)
+	}
+
+	// smooth edges, half-transparent per-object fill
+	style = DefaultOverlayStyle
+	style.Palette = []color.Color{color.RGBA{B: 255, A: 255}}
+	style.Fill = 0.5
+	style.Labels = true
+	out, err = DrawOverlay(context.Background(), mask, contours, style)
+	if err != nil {
+		t.Fatalf("got error while drawing overlay:\n%s", err.Error())
+	}
+	if c := rgba(out, 155, 100); c.B < 120 || c.B > 136 || c.R != 0 {
+		t.Errorf("got %v inside the filled ring, want half blue", c)
+	}
+	if c := rgba(out, 127, 100); c != (color.RGBA{255, 255, 255, 255}) {
+		t.Errorf("got %v in the hole, want it left open", c)
+	}
+	if c := rgba(out, 100, 100); c.B < 120 || c.B > 136 {
+		t.Errorf("got %v inside the filled dot, want half blue", c)
+	}
+	soft := false
+	for x := 160; x < 180; x++ {
+		if c := rgba(out, x, 100); c.R == c.G && c.R > 0 && c.R < 255 {
+			soft = true
+		}
+	}
+	if !soft {
+		t.Errorf("got no blended edge pixels with antialiasing")
+	}
+}
@@ -0,0 +1,179 @@
+package imageutil
+
+import (
+	"context"
+	_ "embed"
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Contour reports
+// -----------------------------------------------------------------------------
+
+// ReportVersion - version of the report layout. It changes whenever a field
+// is renamed or removed; new optional fields keep it.
+const ReportVersion = 1
+
+// ReportSchemaID - $id of the JSON Schema describing this report version.
+const ReportSchemaID = "https://github.com/rifux/Go-BasicBorderScanner/schema/report-v1.json"
+
+// ReportSchema - JSON Schema of the JSON report and of every NDJSON line.
+//
+//go:embed report.schema.json
+var ReportSchema []byte
+
+// ReportImage - the scanned image.
+type ReportImage struct {
+	Path   string `json:"path,omitempty"`
+	Format string `json:"format,omitempty"` // decoder name, e.g. "png"
+	Width  int    `json:"width"`
+	Height int    `json:"height"`
+}
+
+// ReportThreshold - how the image was binarized. Value is nil for modes
+// without a single global threshold.
+type ReportThreshold struct {
+	Mode  SegmentMode `json:"mode"`
+	Value *int        `json:"value"`
+}
+
+// ReportContour - one contour with its points and metrics.
+type ReportContour struct {
+	ID          int       `json:"id"`
+	Parent      int       `json:"parent"`
+	Hole        bool      `json:"hole"`
+	Points      [][2]int  `json:"points"`
+	Area        float64   `json:"area"`
+	Perimeter   float64   `json:"perimeter"`
+	Circularity float64   `json:"circularity"`
+	BBox        [4]int    `json:"bbox"` // x, y, width, height
+	Shape       ShapeKind `json:"shape"`
+	Vertices    int       `json:"vertices"`
+}
+
+// Report - everything known about one scan, in a stable layout for other
+// programs; see ReportSchema.
+type Report struct {
+	Schema    string          `json:"$schema"`
+	Version   int             `json:"version"`
+	Image     ReportImage     `json:"image"`
+	Threshold ReportThreshold `json:"threshold"`
+	Contours  []ReportContour `json:"contours"`
+}
+
+// NewReport measures the contours and collects them with the image
+// metadata.
+func NewReport(ctx context.Context, img ReportImage, threshold ReportThreshold, contours []Contour) (*Report, error) {
+	ms, err := MeasureContours(ctx, contours)
+	if err != nil {
+		return nil, err
+	}
+	r := &Report{
+		Schema:    ReportSchemaID,
+		Version:   ReportVersion,
+		Image:     img,
+		Threshold: threshold,
+		Contours:  make([]ReportContour, len(contours)),
+	}
+	for i, c := range contours {
+		m := ms[i]
+		pts := make([][2]int, len(c.Points))
+		for j, p := range c.Points {
+			pts[j] = [2]int{p.X, p.Y}
+		}
+		r.Contours[i] = ReportContour{
+			ID:          c.ID,
+			Parent:      c.Parent,
+			Hole:        c.Hole,
+			Points:      pts,
+			Area:        m.Area,
+			Perimeter:   m.Perimeter,
+			Circularity: m.Circularity,
+			BBox:        [4]int{m.X, m.Y, m.Width, m.Height},
+			Shape:       m.Shape,
+			Vertices:    m.Vertices,
+		}
+	}
+	return r, nil
+}
+
+// ReportFormats - formats understood by WriteReport.
+var ReportFormats = []string{"json", "ndjson", "csv"}
+
+// WriteReport writes r as "json" (one object), "ndjson" (a header line
+// with record "header", then one line with record "contour" per contour)
+// or "csv" (one row per contour, image columns repeated, points as
+// "x y;x y;...").
+func WriteReport(w io.Writer, r *Report, format string) error {
+	switch strings.ToLower(format) {
+	case "json":
+		// compact: the point lists would take a line per coordinate
+		return json.NewEncoder(w).Encode(r)
+	case "ndjson":
+		return writeReportNDJSON(w, r)
+	case "csv":
+		return writeReportCSV(w, r)
+	}
+	return fmt.Errorf("unsupported report format %q (want %s)", format, strings.Join(ReportFormats, ", "))
+}
+
+func writeReportNDJSON(w io.Writer, r *Report) error {
+	enc := json.NewEncoder(w)
+	header := struct {
+		Record    string          `json:"record"`
+		Schema    string          `json:"$schema"`
+		Version   int             `json:"version"`
+		Image     ReportImage     `json:"image"`
+		Threshold ReportThreshold `json:"threshold"`
+		Contours  int             `json:"contours"`
+	}{"header", r.Schema, r.Version, r.Image, r.Threshold, len(r.Contours)}
+	if err := enc.Encode(header); err != nil {
+		return err
+	}
+	for _, c := range r.Contours {
+		line := struct {
+			Record string `json:"record"`
+			ReportContour
+		}{"contour", c}
+		if err := enc.Encode(line); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeReportCSV(w io.Writer, r *Report) error {
+	cw := csv.NewWriter(w)
+	cw.Write([]string{"version", "image", "image_width", "image_height", "threshold_mode", "threshold",
+		"id", "parent", "hole", "area", "perimeter", "circularity", "x", "y", "width", "height",
+		"shape", "vertices", "points"})
+	threshold := ""
+	if r.Threshold.Value != nil {
+		threshold = strconv.Itoa(*r.Threshold.Value)
+	}
+	ff := func(v float64) string { return strconv.FormatFloat(v, 'f', 3, 64) }
+	for _, c := range r.Contours {
+		var pts strings.Builder
+		for i, p := range c.Points {
+			if i > 0 {
+				pts.WriteByte(';')
+			}
+			fmt.Fprintf(&pts, "%d %d", p[0], p[1])
+		}
+		cw.Write([]string{
+			strconv.Itoa(r.Version), r.Image.Path, strconv.Itoa(r.Image.Width), strconv.Itoa(r.Image.Height),
+			string(r.Threshold.Mode), threshold,
+			strconv.Itoa(c.ID), strconv.Itoa(c.Parent), strconv.FormatBool(c.Hole),
+			ff(c.Area), ff(c.Perimeter), ff(c.Circularity),
+			strconv.Itoa(c.BBox[0]), strconv.Itoa(c.BBox[1]), strconv.Itoa(c.BBox[2]), strconv.Itoa(c.BBox[3]),
+			string(c.Shape), strconv.Itoa(c.Vertices), pts.String(),
+		})
+	}
+	cw.Flush()
+	return cw.Error()
+}
@@ -0,0 +1,105 @@
+package imageutil
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"encoding/csv"
+	"encoding/json"
+	"strings"
+	"testing"
+)
+
+func TestWriteReport(t *testing.T) {
+	mask := filledShape(func(p PointF) bool {
+		d := p.Dist(PointF{100, 100})
+		return d < 70 && d > 40
+	})
+	contours, err := FindContours(context.Background(), mask)
+	if err != nil {
+		t.Fatalf("got error while finding contours:\n%s", err.Error())
+	}
+	level := 128
+	r, err := NewReport(context.Background(),
+		ReportImage{Path: "ring.png", Format: "png", Width: 200, Height: 200},
+		ReportThreshold{Mode: ModeOtsu, Value: &level}, contours)
+	if err != nil {
+		t.Fatalf("got error while building report:\n%s", err.Error())
+	}
+
+	// JSON round trip
+	var buf bytes.Buffer
+	if err := WriteReport(&buf, r, "json"); err != nil {
+		t.Fatalf("got error while writing JSON:\n%s", err.Error())
+	}
+	var back Report
+	if err := json.Unmarshal(buf.Bytes(), &back); err != nil {
+		t.Fatalf("got error while parsing JSON:\n%s", err.Error())
+	}
+	if back.Version != ReportVersion || len(back.Contours) != 2 || *back.Threshold.Value != 128 {
+		t.Errorf("unexpected report: %+v", back)
+	}
+	if c := back.Contours[1]; !c.Hole || c.Parent != 1 || len(c.Points) != len(contours[1].Points) || c.Area <= 0 {
+		t.Errorf("unexpected hole contour: %+v", c)
+	}
+
+	// every field the schema requires is written
+	var schema struct {
+		ID   string `json:"$id"`
+		Defs map[string]struct {
+			Required []string
+		} `json:"$defs"`
+	}
+	if err := json.Unmarshal(ReportSchema, &schema); err != nil {
+		t.Fatalf("got error while parsing the schema:\n%s", err.Error())
+	}
+	if schema.ID != ReportSchemaID {
+		t.Errorf("schema $id %q, want %q", schema.ID, ReportSchemaID)
+	}
+	var doc struct{ Contours []map[string]any }
+	if err := json.Unmarshal(buf.Bytes(), &doc); err != nil {
+		t.Fatalf("got error while parsing JSON:\n%s", err.Error())
+	}
+	for _, key := range schema.Defs["contour"].Required {
+		if _, ok := doc.Contours[0][key]; !ok {
+			t.Errorf("contour is missing required field %q", key)
+		}
+	}
+
+	// NDJSON: header and one line per contour
+	buf.Reset()
+	if err := WriteReport(&buf, r, "ndjson"); err != nil {
+		t.Fatalf("got error while writing NDJSON:\n%s", err.Error())
+	}
+	var records []string
+	sc := bufio.NewScanner(&buf)
+	sc.Buffer(nil, 1<<20)
+	for sc.Scan() {
+		var line struct{ Record string }
+		if err := json.Unmarshal(sc.Bytes(), &line); err != nil {
+			t.Fatalf("got error while parsing NDJSON line:\n%s", err.Error())
+		}
+		records = append(records, line.Record)
+	}
+	if strings.Join(records, ",") != "header,contour,contour" {
+		t.Errorf("got records %v", records)
+	}
+
+	// CSV: header and one row per contour, empty threshold column
+	r.Threshold.Value = nil
+	buf.Reset()
+	if err := WriteReport(&buf, r, "csv"); err != nil {
+		t.Fatalf("got error while writing CSV:\n%s", err.Error())
+	}
+	rows, err := csv.NewReader(&buf).ReadAll()
+	if err != nil {
+		t.Fatalf("got error while parsing CSV:\n%s", err.Error())
+	}
+	if len(rows) != 3 || rows[1][5] != "" || !strings.Contains(rows[1][18], ";") {
+		t.Errorf("unexpected CSV rows: %v", rows[:1])
+	}
+
+	if err := WriteReport(&buf, r, "xml"); err == nil {
+		t.Errorf("expected an error for an unknown format")
+	}
+}
@@ -68,6 +68,23 @@ func DrawScannedContours(ctx context.Context, src image.Image) (image.Image, err
 	dst := image.NewRGBA(bounds)
 	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
 
+	contours, err := scanContours(ctx, src, nil)
+	if err != nil {
+		return nil, err
+	}
+	drawScanPoints(dst, contours)
+	return dst, ctx.Err()
+}
+
+// scanRowFunc - called after every scanned row y with the contour points
+// found so far and the series still open in that row.
+type scanRowFunc func(y int, contours map[int][]image.Point, active []activeSer)
+
+// scanContours runs the scanning algorithm over src and returns the points
+// of every contour by ID. onRow, if not nil, sees the state after each row.
+func scanContours(ctx context.Context, src image.Image, onRow scanRowFunc) (map[int][]image.Point, error) {
+	bounds := src.Bounds()
+
 	// storage of points by contour ID
 	contours := make(map[int][]image.Point)
 	nextID := 1
@@ -158,6 +175,9 @@ func DrawScannedContours(ctx context.Context, src image.Image) (image.Image, err
 		}
 
 		prev = next
+		if onRow != nil {
+			onRow(y, contours, prev)
+		}
 	}
 
 	// -------------------------------------------------------------------------
@@ -170,9 +190,12 @@ func DrawScannedContours(ctx context.Context, src image.Image) (image.Image, err
 			image.Pt(as.ser.startX, as.ser.y))
 	}
 
-	// -------------------------------------------------------------------------
-	// Draw
-	// -------------------------------------------------------------------------
+	return contours, ctx.Err()
+}
+
+// drawScanPoints marks the contour points inside dst in red.
+func drawScanPoints(dst *image.RGBA, contours map[int][]image.Point) {
+	bounds := dst.Bounds()
 	contourColor := color.RGBA{R: 255, G: 0, B: 0, A: 255}
 	for _, pts := range contours {
 		for _, p := range pts {
@@ -181,6 +204,4 @@ func DrawScannedContours(ctx context.Context, src image.Image) (image.Image, err
 			}
 		}
 	}
-
-	return dst, ctx.Err()
 }
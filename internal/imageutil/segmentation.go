@@ -0,0 +1,281 @@
+package imageutil
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"math"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Segmentation modes
+// -----------------------------------------------------------------------------
+
+// SegmentMode selects how an image is turned into the binary mask consumed by
+// the scanner. Masks are *image.Gray with object pixels black (0) and
+// background pixels white (255).
+type SegmentMode string
+
+const (
+	ModeOtsu    SegmentMode = "otsu"    // Otsu threshold on luminance
+	ModeHSV     SegmentMode = "hsv"     // hue/saturation/value range keying
+	ModeLab     SegmentMode = "lab"     // Lab distance from a reference colour
+	ModeChannel SegmentMode = "channel" // Otsu threshold on a single channel
+	ModeAlpha   SegmentMode = "alpha"   // alpha cutoff for transparent images
+	ModeAuto    SegmentMode = "auto"    // alpha if the image has transparency, otherwise otsu
+	ModeKMeans  SegmentMode = "kmeans"  // k-means colour clusters, one mask each (see KMeansSegment)
+	ModeSobel   SegmentMode = "sobel"   // Otsu threshold on Sobel gradient magnitude
+	ModeScharr  SegmentMode = "scharr"  // Otsu threshold on Scharr gradient magnitude
+	ModeCanny   SegmentMode = "canny"   // Canny edge detector
+)
+
+// DefaultAlphaCutoff - alpha value above which a pixel counts as object.
+const DefaultAlphaCutoff = 127
+
+// Channel - one component of an RGBA colour.
+type Channel int
+
+const (
+	ChannelR Channel = iota
+	ChannelG
+	ChannelB
+	ChannelA
+)
+
+// ParseChannel converts "r", "g", "b" or "a" (or the full names) to a Channel.
+func ParseChannel(s string) (Channel, error) {
+	switch strings.ToLower(s) {
+	case "r", "red":
+		return ChannelR, nil
+	case "g", "green":
+		return ChannelG, nil
+	case "b", "blue":
+		return ChannelB, nil
+	case "a", "alpha":
+		return ChannelA, nil
+	}
+	return 0, fmt.Errorf("unknown channel %q (want r, g, b or a)", s)
+}
+
+func (c Channel) String() string {
+	return [...]string{"r", "g", "b", "a"}[c]
+}
+
+// HSVRange - inclusive HSV box used for colour keying.
+// If HMin > HMax the hue range wraps through 0 (e.g. 340..20 for reds).
+type HSVRange struct {
+	HMin, HMax float64 // degrees [0, 360)
+	SMin, SMax float64 // [0, 1]
+	VMin, VMax float64 // [0, 1]
+}
+
+// Contains reports whether c lies inside the range.
+func (r HSVRange) Contains(c HSV) bool {
+	inHue := c.H >= r.HMin && c.H <= r.HMax
+	if r.HMin > r.HMax {
+		inHue = c.H >= r.HMin || c.H <= r.HMax
+	}
+	return inHue &&
+		c.S >= r.SMin && c.S <= r.SMax &&
+		c.V >= r.VMin && c.V <= r.VMax
+}
+
+// HSVRangeAround returns the range centred on c with the given hue tolerance
+// (degrees) and saturation/value tolerances.
+func HSVRangeAround(c color.Color, hueTol, satTol, valTol float64) HSVRange {
+	hsv := ToHSV(c)
+	r := HSVRange{
+		HMin: math.Mod(hsv.H-hueTol+360, 360),
+		HMax: math.Mod(hsv.H+hueTol, 360),
+		SMin: math.Max(0, hsv.S-satTol),
+		SMax: math.Min(1, hsv.S+satTol),
+		VMin: math.Max(0, hsv.V-valTol),
+		VMax: math.Min(1, hsv.V+valTol),
+	}
+	if hueTol >= 180 {
+		r.HMin, r.HMax = 0, 360
+	}
+	return r
+}
+
+// SegmentOptions - parameters for Segment. Only the fields used by Mode are read.
+type SegmentOptions struct {
+	Mode        SegmentMode
+	HSV         HSVRange     // ModeHSV
+	Reference   color.Color  // ModeLab
+	MaxDistance float64      // ModeLab, CIE76 delta E
+	Channel     Channel      // ModeChannel
+	AlphaCutoff uint8        // ModeAlpha, ModeAuto
+	K           int          // ModeKMeans, number of clusters
+	Seed        int64        // ModeKMeans
+	Canny       CannyOptions // ModeCanny
+}
+
+// Segment produces the binary mask for src according to opts.
+func Segment(ctx context.Context, src image.Image, opts SegmentOptions) (image.Image, error) {
+	mode := ResolveMode(src, opts.Mode)
+	switch mode {
+	case ModeOtsu, "":
+		return OtsuBinarize(ctx, src)
+	case ModeHSV:
+		return HSVSegment(ctx, src, opts.HSV)
+	case ModeLab:
+		if opts.Reference == nil {
+			return nil, fmt.Errorf("segment mode %q needs a reference colour", opts.Mode)
+		}
+		return LabSegment(ctx, src, opts.Reference, opts.MaxDistance)
+	case ModeChannel:
+		return ChannelBinarize(ctx, src, opts.Channel)
+	case ModeAlpha:
+		return AlphaBinarize(ctx, src, opts.AlphaCutoff)
+	case ModeSobel:
+		return GradientEdges(ctx, src, Sobel)
+	case ModeScharr:
+		return GradientEdges(ctx, src, Scharr)
+	case ModeCanny:
+		return Canny(ctx, src, opts.Canny)
+	case ModeKMeans:
+		return nil, fmt.Errorf("segment mode %q produces one mask per cluster, use KMeansSegment", opts.Mode)
+	default:
+		return nil, fmt.Errorf("unknown segment mode %q", opts.Mode)
+	}
+}
+
+// ResolveMode returns the mode Segment uses for src: an empty mode is
+// ModeOtsu, ModeAuto becomes ModeAlpha for images with transparency and
+// ModeOtsu otherwise.
+func ResolveMode(src image.Image, mode SegmentMode) SegmentMode {
+	switch mode {
+	case "":
+		return ModeOtsu
+	case ModeAuto:
+	default:
+		return mode
+	}
+	if HasTransparency(src) {
+		return ModeAlpha
+	}
+	return ModeOtsu
+}
+
+// SegmentThreshold returns the global threshold Segment applies to src
+// with opts, and false for modes that do not use one. For the gradient
+// modes it is the level on the magnitude scaled to [0, 255].
+func SegmentThreshold(ctx context.Context, src image.Image, opts SegmentOptions) (int, bool, error) {
+	mode := ResolveMode(src, opts.Mode)
+	switch mode {
+	case ModeOtsu, "":
+		t, err := OtsuLevel(ctx, src)
+		return t, err == nil, err
+	case ModeChannel:
+		t, err := otsuLevelFunc(ctx, src, channelLevel(opts.Channel))
+		return t, err == nil, err
+	case ModeAlpha:
+		return int(opts.AlphaCutoff), true, nil
+	case ModeSobel, ModeScharr:
+		op := Sobel
+		if mode == ModeScharr {
+			op = Scharr
+		}
+		_, level, err := gradientEdgeLevel(ctx, src, op)
+		return int(level), err == nil, err
+	}
+	return 0, false, nil
+}
+
+// -----------------------------------------------------------------------------
+// Colour keying
+// -----------------------------------------------------------------------------
+
+// HSVSegment marks every pixel whose HSV colour lies inside r as object (black).
+func HSVSegment(ctx context.Context, src image.Image, r HSVRange) (image.Image, error) {
+	return maskWhere(ctx, src.Bounds(), func(x, y int) bool {
+		return r.Contains(ToHSV(src.At(x, y)))
+	})
+}
+
+// LabSegment marks every pixel within maxDist (CIE76 delta E) of ref as object (black).
+func LabSegment(ctx context.Context, src image.Image, ref color.Color, maxDist float64) (image.Image, error) {
+	refLab := ToLab(ref)
+	return maskWhere(ctx, src.Bounds(), func(x, y int) bool {
+		return ToLab(src.At(x, y)).Distance(refLab) <= maxDist
+	})
+}
+
+// ChannelBinarize applies Otsu's method to a single channel of src.
+// The polarity matches OtsuBinarize: values above the threshold become white.
+func ChannelBinarize(ctx context.Context, src image.Image, ch Channel) (image.Image, error) {
+	return otsuBinarizeFunc(ctx, src, channelLevel(ch))
+}
+
+// channelLevel returns a function reading channel ch of a colour.
+func channelLevel(ch Channel) func(color.Color) uint8 {
+	return func(c color.Color) uint8 {
+		n := color.NRGBAModel.Convert(c).(color.NRGBA)
+		switch ch {
+		case ChannelR:
+			return n.R
+		case ChannelG:
+			return n.G
+		case ChannelB:
+			return n.B
+		default:
+			return n.A
+		}
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Transparency
+// -----------------------------------------------------------------------------
+
+// AlphaBinarize marks every pixel with alpha above cutoff as object (black),
+// so the mask follows the outline of cut-out images regardless of brightness.
+func AlphaBinarize(ctx context.Context, src image.Image, cutoff uint8) (image.Image, error) {
+	return maskWhere(ctx, src.Bounds(), func(x, y int) bool {
+		_, _, _, a := src.At(x, y).RGBA()
+		return uint8(a>>8) > cutoff
+	})
+}
+
+// HasTransparency reports whether at least 1% of the pixels of src are not
+// fully opaque. Stray semi-transparent pixels from encoders do not count.
+func HasTransparency(src image.Image) bool {
+	if o, ok := src.(interface{ Opaque() bool }); ok && o.Opaque() {
+		return false
+	}
+	bounds := src.Bounds()
+	limit := bounds.Dx() * bounds.Dy() / 100
+	n := 0
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if _, _, _, a := src.At(x, y).RGBA(); a != 0xffff {
+				n++
+				if n > limit {
+					return true
+				}
+			}
+		}
+	}
+	return false
+}
+
+// maskWhere builds a mask that is black wherever isObject returns true.
+func maskWhere(ctx context.Context, bounds image.Rectangle, isObject func(x, y int) bool) (image.Image, error) {
+	out := image.NewGray(bounds)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			if isObject(x, y) {
+				out.SetGray(x, y, color.Gray{Y: 0})
+			} else {
+				out.SetGray(x, y, color.Gray{Y: 255})
+			}
+		}
+	}
+	return out, nil
+}
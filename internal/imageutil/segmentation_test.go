@@ -0,0 +1,88 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// redOnGreen returns a 20x20 green image with a red 10x10 square in the middle.
+// Both colours have (almost) the same luminance, so OtsuBinarize cannot split them.
+func redOnGreen() *image.NRGBA {
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			c := color.NRGBA{R: 0, G: 76, B: 0, A: 255}
+			if x >= 5 && x < 15 && y >= 5 && y < 15 {
+				c = color.NRGBA{R: 150, G: 0, B: 0, A: 255}
+			}
+			img.SetNRGBA(x, y, c)
+		}
+	}
+	return img
+}
+
+func checkSquareMask(t *testing.T, mask image.Image) {
+	t.Helper()
+	for y := 0; y < 20; y++ {
+		for x := 0; x < 20; x++ {
+			inside := x >= 5 && x < 15 && y >= 5 && y < 15
+			got := color.GrayModel.Convert(mask.At(x, y)).(color.Gray).Y
+			if inside && got != 0 || !inside && got != 255 {
+				t.Fatalf("pixel (%d,%d): got %d, inside=%v", x, y, got, inside)
+			}
+		}
+	}
+}
+
+func TestHSVSegment(t *testing.T) {
+	mask, err := HSVSegment(context.Background(), redOnGreen(),
+		HSVRange{HMin: 340, HMax: 20, SMin: 0.5, SMax: 1, VMin: 0.2, VMax: 1})
+	if err != nil {
+		t.Fatalf("got error while segmenting image:\n%s", err.Error())
+	}
+	checkSquareMask(t, mask)
+}
+
+func TestLabSegment(t *testing.T) {
+	mask, err := LabSegment(context.Background(), redOnGreen(),
+		color.NRGBA{R: 160, G: 10, B: 10, A: 255}, 20)
+	if err != nil {
+		t.Fatalf("got error while segmenting image:\n%s", err.Error())
+	}
+	checkSquareMask(t, mask)
+}
+
+func TestChannelBinarize(t *testing.T) {
+	// the square has no green, so it falls below the threshold and turns black
+	mask, err := ChannelBinarize(context.Background(), redOnGreen(), ChannelG)
+	if err != nil {
+		t.Fatalf("got error while segmenting image:\n%s", err.Error())
+	}
+	checkSquareMask(t, mask)
+}
+
+func TestAlphaAuto(t *testing.T) {
+	// transparent background with an opaque dark square: same brightness
+	// everywhere once alpha is dropped, so only the alpha channel finds it
+	img := image.NewNRGBA(image.Rect(0, 0, 20, 20))
+	for y := 5; y < 15; y++ {
+		for x := 5; x < 15; x++ {
+			img.SetNRGBA(x, y, color.NRGBA{A: 255})
+		}
+	}
+	if !HasTransparency(img) {
+		t.Fatalf("expected image to have transparency")
+	}
+	mask, err := Segment(context.Background(), img,
+		SegmentOptions{Mode: ModeAuto, AlphaCutoff: DefaultAlphaCutoff})
+	if err != nil {
+		t.Fatalf("got error while segmenting image:\n%s", err.Error())
+	}
+	checkSquareMask(t, mask)
+
+	if HasTransparency(redOnGreen()) {
+		t.Fatalf("opaque image reported as transparent")
+	}
+}
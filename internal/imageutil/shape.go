@@ -0,0 +1,201 @@
+package imageutil
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// -----------------------------------------------------------------------------
+// Shape classification
+// -----------------------------------------------------------------------------
+
+// ShapeKind - class assigned to a contour by ClassifyShape.
+type ShapeKind string
+
+const (
+	ShapeCircle    ShapeKind = "circle"
+	ShapeEllipse   ShapeKind = "ellipse"
+	ShapeTriangle  ShapeKind = "triangle"
+	ShapeSquare    ShapeKind = "square"
+	ShapeRectangle ShapeKind = "rectangle"
+	ShapePolygon   ShapeKind = "polygon" // regular polygon with Shape.Vertices corners
+	ShapeStar      ShapeKind = "star"
+	ShapeIrregular ShapeKind = "irregular"
+)
+
+const (
+	shapeEpsilon     = 0.015 // polygon approximation tolerance, fraction of the hull perimeter
+	shapeMinSolidity = 0.9   // less solid shapes are stars or irregular
+	shapeRoundness   = 0.9   // minor / major axis above which an ellipse is a circle
+	shapeAngleTol    = 10.0  // degrees a corner of a regular polygon may be off
+	shapeSideRatio   = 0.75  // shortest / longest side of a regular polygon
+	shapeSquareRatio = 0.9   // shorter / longer side of a square
+)
+
+// Shape - result of ClassifyShape.
+type Shape struct {
+	Kind        ShapeKind
+	Vertices    int     // corners of polygons, points of stars, 0 for round shapes
+	Circularity float64 // 4*pi*area / perimeter^2 of the convex hull, 1 for a circle
+	Solidity    float64 // area / convex hull area
+}
+
+func (s Shape) String() string {
+	if s.Kind == ShapePolygon {
+		return fmt.Sprintf("%d-gon", s.Vertices)
+	}
+	return string(s.Kind)
+}
+
+// ClassifyShape labels a contour as circle, ellipse, triangle, square,
+// rectangle, regular polygon, star or irregular.
+//
+// Convex shapes are approximated both by a polygon (Douglas-Peucker on the
+// convex hull) and by the ellipse with the same second moments; whichever
+// follows the contour more closely decides between the round and the
+// polygonal classes. Corner angles and side lengths then tell regular
+// polygons from irregular ones. Non-convex shapes whose corners alternate
+// between convex and reflex are stars.
+func ClassifyShape(points []image.Point) Shape {
+	pts := toPointsF(points)
+	hull := ConvexHull(pts)
+	hullArea := PolygonArea(hull)
+	if len(hull) < 3 || hullArea == 0 {
+		return Shape{Kind: ShapeIrregular}
+	}
+	hullPerim := Perimeter(hull)
+	s := Shape{
+		Circularity: 4 * math.Pi * hullArea / (hullPerim * hullPerim),
+		Solidity:    PolygonArea(pts) / hullArea,
+	}
+	eps := math.Max(1, shapeEpsilon*hullPerim)
+
+	if s.Solidity < shapeMinSolidity {
+		if n := starPoints(dropCollinear(ApproxPolygon(pts, eps), eps)); n >= 3 {
+			s.Kind, s.Vertices = ShapeStar, n
+		} else {
+			s.Kind = ShapeIrregular
+		}
+		return s
+	}
+
+	approx := dropCollinear(ApproxPolygon(hull, eps), eps)
+	if len(approx) < 3 || ellipseResidual(pts) < polygonResidual(pts, approx) {
+		if a, b, _ := PolygonMoments(pts).Ellipse(); b >= shapeRoundness*a {
+			s.Kind = ShapeCircle
+		} else {
+			s.Kind = ShapeEllipse
+		}
+		return s
+	}
+
+	s.Kind, s.Vertices = polygonKind(approx)
+	return s
+}
+
+// dropCollinear removes corners that lie within eps of the line through
+// their neighbours, such as the start point Douglas-Peucker always keeps.
+func dropCollinear(poly []PointF, eps float64) []PointF {
+	for i := 0; len(poly) > 3 && i < len(poly); {
+		n := len(poly)
+		if segmentDistance(poly[i], poly[(i+n-1)%n], poly[(i+1)%n]) <= eps {
+			poly = append(poly[:i:i], poly[i+1:]...)
+			continue
+		}
+		i++
+	}
+	return poly
+}
+
+// polygonKind names a convex polygon from its corner angles and side lengths.
+func polygonKind(poly []PointF) (ShapeKind, int) {
+	n := len(poly)
+	ideal := 180 * float64(n-2) / float64(n)
+	regularAngles := true
+	minSide, maxSide := math.Inf(1), 0.0
+	for i, p := range poly {
+		prev, next := poly[(i+n-1)%n], poly[(i+1)%n]
+		if math.Abs(cornerAngle(prev, p, next)-ideal) > shapeAngleTol {
+			regularAngles = false
+		}
+		side := p.Dist(next)
+		minSide, maxSide = math.Min(minSide, side), math.Max(maxSide, side)
+	}
+
+	switch {
+	case n == 3:
+		return ShapeTriangle, 3
+	case n == 4 && regularAngles && minSide >= shapeSquareRatio*maxSide:
+		return ShapeSquare, 4
+	case n == 4 && regularAngles:
+		return ShapeRectangle, 4
+	case n > 4 && regularAngles && minSide >= shapeSideRatio*maxSide:
+		return ShapePolygon, n
+	}
+	return ShapeIrregular, n
+}
+
+// cornerAngle returns the angle at b between the edges to a and c, in degrees.
+func cornerAngle(a, b, c PointF) float64 {
+	u, v := a.Sub(b), c.Sub(b)
+	if u.Len() == 0 || v.Len() == 0 {
+		return 180
+	}
+	cos := u.Dot(v) / (u.Len() * v.Len())
+	return math.Acos(math.Max(-1, math.Min(1, cos))) * 180 / math.Pi
+}
+
+// starPoints returns the number of points of a star-shaped polygon whose
+// corners alternate between convex and reflex, or 0 if poly is no star.
+func starPoints(poly []PointF) int {
+	n := len(poly)
+	if n < 6 || n%2 != 0 {
+		return 0
+	}
+	orient := math.Copysign(1, SignedArea(poly))
+	prevConvex := false
+	for i := 0; i <= n; i++ {
+		a, b, c := poly[(i+n-1)%n], poly[i%n], poly[(i+1)%n]
+		convex := b.Sub(a).Cross(c.Sub(b))*orient > 0
+		if i > 0 && convex == prevConvex {
+			return 0
+		}
+		prevConvex = convex
+	}
+	return n / 2
+}
+
+// polygonResidual returns the mean distance of pts to the closed polygon poly.
+func polygonResidual(pts, poly []PointF) float64 {
+	var sum float64
+	for _, p := range pts {
+		d := math.Inf(1)
+		for i := range poly {
+			d = math.Min(d, segmentDistance(p, poly[i], poly[(i+1)%len(poly)]))
+		}
+		sum += d
+	}
+	return sum / float64(len(pts))
+}
+
+// ellipseResidual returns the mean radial distance of pts to the ellipse
+// with the same moments as the polygon they enclose.
+func ellipseResidual(pts []PointF) float64 {
+	m := PolygonMoments(pts)
+	a, b, theta := m.Ellipse()
+	if b == 0 {
+		return math.Inf(1)
+	}
+	c := m.Centroid()
+	sin, cos := math.Sincos(theta)
+	var sum float64
+	for _, p := range pts {
+		d := p.Sub(c)
+		u, v := d.X*cos+d.Y*sin, -d.X*sin+d.Y*cos
+		if r := math.Hypot(u/a, v/b); r > 0 {
+			sum += d.Len() * math.Abs(1-1/r)
+		}
+	}
+	return sum / float64(len(pts))
+}
@@ -0,0 +1,92 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// filledShape returns a white 200x200 mask with the points where inside
+// reports true painted black.
+func filledShape(inside func(p PointF) bool) *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 200, 200))
+	for y := 0; y < 200; y++ {
+		for x := 0; x < 200; x++ {
+			v := uint8(255)
+			if inside(PointF{float64(x) + 0.5, float64(y) + 0.5}) {
+				v = 0
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+// regularPolygon returns n corners on a circle, every second one at radius
+// r2 (r2 == r gives a regular polygon, r2 < r a star).
+func regularPolygon(n int, r, r2, rot float64) []PointF {
+	pts := make([]PointF, n)
+	for i := range pts {
+		a := rot + 2*math.Pi*float64(i)/float64(n)
+		rad := r
+		if i%2 == 1 {
+			rad = r2
+		}
+		pts[i] = PointF{100 + rad*math.Cos(a), 100 + rad*math.Sin(a)}
+	}
+	return pts
+}
+
+func polygonShape(pts []PointF) func(PointF) bool {
+	return func(p PointF) bool { return insidePolygon(p, pts) }
+}
+
+func TestClassifyShape(t *testing.T) {
+	rect := RotatedRect{Center: PointF{100, 100}, Axis: PointF{math.Cos(0.35), math.Sin(0.35)}, W: 120, H: 60}.Corners()
+	square := RotatedRect{Center: PointF{100, 100}, Axis: PointF{1, 0}, W: 90, H: 90}.Corners()
+
+	tests := []struct {
+		name     string
+		inside   func(PointF) bool
+		kind     ShapeKind
+		vertices int
+	}{
+		{"circle", func(p PointF) bool { return p.Dist(PointF{100, 100}) < 60 }, ShapeCircle, 0},
+		{"ellipse", func(p PointF) bool {
+			d := p.Sub(PointF{100, 100})
+			u, v := d.X*0.8+d.Y*0.6, -d.X*0.6+d.Y*0.8
+			return (u/80)*(u/80)+(v/35)*(v/35) < 1
+		}, ShapeEllipse, 0},
+		{"triangle", polygonShape([]PointF{{30, 160}, {170, 170}, {90, 30}}), ShapeTriangle, 3},
+		{"square", polygonShape(square[:]), ShapeSquare, 4},
+		{"rectangle", polygonShape(rect[:]), ShapeRectangle, 4},
+		{"hexagon", polygonShape(regularPolygon(6, 70, 70, 0.2)), ShapePolygon, 6},
+		{"star", polygonShape(regularPolygon(10, 80, 32, -math.Pi/2)), ShapeStar, 5},
+		{"irregular", polygonShape([]PointF{{30, 30}, {170, 30}, {170, 80}, {80, 80}, {80, 170}, {30, 170}}), ShapeIrregular, 0},
+	}
+	for _, tt := range tests {
+		contours, err := FindContours(context.Background(), filledShape(tt.inside))
+		if err != nil {
+			t.Fatalf("got error while finding contours:\n%s", err.Error())
+		}
+		s := ClassifyShape(contours[0].Points)
+		if s.Kind != tt.kind || (tt.vertices != 0 && s.Vertices != tt.vertices) {
+			t.Fatalf("%s: got %s (%d vertices, circularity %.2f, solidity %.2f), want %s",
+				tt.name, s.Kind, s.Vertices, s.Circularity, s.Solidity, tt.kind)
+		}
+	}
+}
+
+func TestPolygonMoments(t *testing.T) {
+	// 40x20 rectangle centred on (30, 20), listed counter-clockwise
+	m := PolygonMoments([]PointF{{10, 10}, {10, 30}, {50, 30}, {50, 10}})
+	if math.Abs(m.M00-800) > 1e-9 || m.Centroid().Dist(PointF{30, 20}) > 1e-9 {
+		t.Fatalf("got area %v and centroid %v, want 800 and (30,20)", m.M00, m.Centroid())
+	}
+	xx, xy, yy := m.Covariance()
+	if math.Abs(xx-40.0*40/12) > 1e-9 || math.Abs(xy) > 1e-9 || math.Abs(yy-20.0*20/12) > 1e-9 {
+		t.Fatalf("got covariance %v %v %v", xx, xy, yy)
+	}
+}
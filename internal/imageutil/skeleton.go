@@ -0,0 +1,416 @@
+package imageutil
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Thinning
+// -----------------------------------------------------------------------------
+
+// ThinningMethod selects the skeletonization algorithm.
+type ThinningMethod int
+
+const (
+	ZhangSuen ThinningMethod = iota
+	GuoHall
+)
+
+// ParseThinningMethod converts "zhang-suen" or "guo-hall" to a ThinningMethod.
+func ParseThinningMethod(s string) (ThinningMethod, error) {
+	switch strings.ToLower(s) {
+	case "zhang-suen", "zhangsuen", "zs":
+		return ZhangSuen, nil
+	case "guo-hall", "guohall", "gh":
+		return GuoHall, nil
+	}
+	return 0, fmt.Errorf("unknown thinning method %q (want zhang-suen or guo-hall)", s)
+}
+
+func (m ThinningMethod) String() string {
+	if m == GuoHall {
+		return "guo-hall"
+	}
+	return "zhang-suen"
+}
+
+// bitGrid - binary image with a one-pixel false frame around it.
+type bitGrid struct {
+	w, h   int // padded size
+	origin image.Point
+	px     []bool
+}
+
+func newBitGrid(ctx context.Context, bin image.Image) (*bitGrid, error) {
+	bounds := bin.Bounds()
+	g := &bitGrid{
+		w:      bounds.Dx() + 2,
+		h:      bounds.Dy() + 2,
+		origin: bounds.Min.Sub(image.Pt(1, 1)),
+	}
+	g.px = make([]bool, g.w*g.h)
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			g.px[(y-g.origin.Y)*g.w+(x-g.origin.X)] = isBlack(bin, x, y)
+		}
+	}
+	return g, nil
+}
+
+// neighbours returns P2..P9 (N, NE, E, SE, S, SW, W, NW) of pixel i.
+func (g *bitGrid) neighbours(i int) [8]bool {
+	w := g.w
+	return [8]bool{
+		g.px[i-w], g.px[i-w+1], g.px[i+1], g.px[i+w+1],
+		g.px[i+w], g.px[i+w-1], g.px[i-1], g.px[i-w-1],
+	}
+}
+
+// point converts a grid index to image coordinates.
+func (g *bitGrid) point(i int) image.Point {
+	return image.Pt(i%g.w, i/g.w).Add(g.origin)
+}
+
+// image renders the grid as a mask with set pixels black.
+func (g *bitGrid) image(bounds image.Rectangle) *image.Gray {
+	out := image.NewGray(bounds)
+	draw.Draw(out, bounds, image.White, image.Point{}, draw.Src)
+	for i, on := range g.px {
+		if on {
+			p := g.point(i)
+			out.SetGray(p.X, p.Y, color.Gray{Y: 0})
+		}
+	}
+	return out
+}
+
+// transitions counts false->true changes in the circular sequence P2..P9.
+func transitions(n [8]bool) int {
+	a := 0
+	for k := 0; k < 8; k++ {
+		if !n[k] && n[(k+1)%8] {
+			a++
+		}
+	}
+	return a
+}
+
+func count(n [8]bool) int {
+	b := 0
+	for _, v := range n {
+		if v {
+			b++
+		}
+	}
+	return b
+}
+
+func b2i(v bool) int {
+	if v {
+		return 1
+	}
+	return 0
+}
+
+// Skeletonize thins the black regions of a binary image to one-pixel-wide
+// centrelines. The result is a mask with the skeleton in black.
+func Skeletonize(ctx context.Context, bin image.Image, method ThinningMethod) (image.Image, error) {
+	g, err := newBitGrid(ctx, bin)
+	if err != nil {
+		return nil, err
+	}
+	if err := thin(ctx, g, method); err != nil {
+		return nil, err
+	}
+	return g.image(bin.Bounds()), nil
+}
+
+func thin(ctx context.Context, g *bitGrid, method ThinningMethod) error {
+	var del []int
+	for changed := true; changed; {
+		changed = false
+		for iter := 0; iter < 2; iter++ {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			del = del[:0]
+			for y := 1; y < g.h-1; y++ {
+				for x := 1; x < g.w-1; x++ {
+					i := y*g.w + x
+					if !g.px[i] {
+						continue
+					}
+					n := g.neighbours(i)
+					if method == GuoHall && guoHallDeletable(n, iter) ||
+						method == ZhangSuen && zhangSuenDeletable(n, iter) {
+						del = append(del, i)
+					}
+				}
+			}
+			for _, i := range del {
+				g.px[i] = false
+			}
+			changed = changed || len(del) > 0
+		}
+	}
+	return nil
+}
+
+func zhangSuenDeletable(n [8]bool, iter int) bool {
+	p2, p4, p6, p8 := n[0], n[2], n[4], n[6]
+	b := count(n)
+	if b < 2 || b > 6 || transitions(n) != 1 {
+		return false
+	}
+	if iter == 0 {
+		return !(p2 && p4 && p6) && !(p4 && p6 && p8)
+	}
+	return !(p2 && p4 && p8) && !(p2 && p6 && p8)
+}
+
+func guoHallDeletable(n [8]bool, iter int) bool {
+	p2, p3, p4, p5, p6, p7, p8, p9 := n[0], n[1], n[2], n[3], n[4], n[5], n[6], n[7]
+	c := b2i(!p2 && (p3 || p4)) + b2i(!p4 && (p5 || p6)) +
+		b2i(!p6 && (p7 || p8)) + b2i(!p8 && (p9 || p2))
+	n1 := b2i(p9 || p2) + b2i(p3 || p4) + b2i(p5 || p6) + b2i(p7 || p8)
+	n2 := b2i(p2 || p3) + b2i(p4 || p5) + b2i(p6 || p7) + b2i(p8 || p9)
+	nn := min(n1, n2)
+	var m bool
+	if iter == 0 {
+		m = (p6 || p7 || !p9) && p8
+	} else {
+		m = (p2 || p3 || !p5) && p4
+	}
+	return c == 1 && nn >= 2 && nn <= 3 && !m
+}
+
+// -----------------------------------------------------------------------------
+// Skeleton graph
+// -----------------------------------------------------------------------------
+
+// NodeKind - type of a skeleton graph node.
+type NodeKind string
+
+const (
+	EndPoint NodeKind = "end"
+	Junction NodeKind = "junction"
+)
+
+// SkeletonNode - end point or junction of a skeleton.
+type SkeletonNode struct {
+	ID     int         `json:"id"`
+	Kind   NodeKind    `json:"kind"`
+	Point  image.Point `json:"point"`
+	Degree int         `json:"degree"` // number of polylines meeting here
+}
+
+// Polyline - skeleton branch between two nodes. Closed loops without any
+// node have From == To == 0.
+type Polyline struct {
+	From   int           `json:"from"`
+	To     int           `json:"to"`
+	Points []image.Point `json:"points"`
+}
+
+// SkeletonGraph - skeleton split into nodes and the polylines joining them.
+type SkeletonGraph struct {
+	Nodes     []SkeletonNode `json:"nodes"`
+	Polylines []Polyline     `json:"polylines"`
+}
+
+// SkeletonToGraph turns a one-pixel-wide skeleton (black on white, e.g. from
+// Skeletonize) into polylines joined at end-point and junction nodes.
+func SkeletonToGraph(ctx context.Context, skel image.Image) (*SkeletonGraph, error) {
+	g, err := newBitGrid(ctx, skel)
+	if err != nil {
+		return nil, err
+	}
+	w := g.w
+	offs := [8]int{-w, -w + 1, 1, w + 1, w, w - 1, -1, -w - 1} // P2..P9
+	isDiag := func(k int) bool { return k%2 == 1 }
+
+	// Step 1: classify pixels.
+	// node[i] - 1-based node ID of pixel i, 0 for plain line pixels.
+	node := make([]int, len(g.px))
+	kind := make([]NodeKind, len(g.px))
+	for i, on := range g.px {
+		if !on {
+			continue
+		}
+		n := g.neighbours(i)
+		b, a := count(n), transitions(n)
+		switch {
+		case a >= 3:
+			kind[i] = Junction
+		case b <= 1 || b == 2 && a == 1:
+			kind[i] = EndPoint
+		}
+	}
+
+	// Step 2: merge touching node pixels into nodes.
+	res := &SkeletonGraph{}
+	for i := range g.px {
+		if kind[i] == "" || node[i] != 0 {
+			continue
+		}
+		id := len(res.Nodes) + 1
+		nd := SkeletonNode{ID: id, Kind: kind[i], Point: g.point(i)}
+		stack := []int{i}
+		node[i] = id
+		for len(stack) > 0 {
+			j := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			if kind[j] == Junction {
+				nd.Kind = Junction
+			}
+			for _, o := range offs {
+				if k := j + o; kind[k] != "" && node[k] == 0 {
+					node[k] = id
+					stack = append(stack, k)
+				}
+			}
+		}
+		res.Nodes = append(res.Nodes, nd)
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Step 3: walk from every node along its branches.
+	visited := make([]bool, len(g.px))
+	linked := map[[2]int]bool{} // directly adjacent node pixels already joined
+
+	// walk follows line pixels from start through first until it reaches a
+	// node pixel or runs out of pixels.
+	walk := func(start, first int) Polyline {
+		pl := Polyline{From: node[start], Points: []image.Point{g.point(start), g.point(first)}}
+		prev, cur := start, first
+		visited[first] = true
+		for node[cur] == 0 {
+			next := -1
+			for pass := 0; pass < 3 && next < 0; pass++ {
+				for k, o := range offs {
+					c := cur + o
+					if c == prev || !g.px[c] {
+						continue
+					}
+					// pass 0 - nodes (except the one we just left),
+					// pass 1 - straight neighbours, pass 2 - diagonals
+					switch {
+					case pass == 0 && node[c] != 0 && !(node[c] == pl.From && len(pl.Points) <= 2):
+					case pass == 1 && node[c] == 0 && !visited[c] && !isDiag(k):
+					case pass == 2 && node[c] == 0 && !visited[c] && isDiag(k):
+					default:
+						continue
+					}
+					next = c
+					break
+				}
+			}
+			if next < 0 {
+				break
+			}
+			visited[next] = true
+			pl.Points = append(pl.Points, g.point(next))
+			prev, cur = cur, next
+		}
+		pl.To = node[cur]
+		return pl
+	}
+
+	for i := range g.px {
+		if node[i] == 0 {
+			continue
+		}
+		for _, o := range offs {
+			j := i + o
+			switch {
+			case !g.px[j] || node[j] == node[i]:
+			case node[j] != 0:
+				key := [2]int{min(i, j), max(i, j)}
+				if !linked[key] {
+					linked[key] = true
+					res.Polylines = append(res.Polylines, Polyline{
+						From: node[i], To: node[j],
+						Points: []image.Point{g.point(i), g.point(j)},
+					})
+				}
+			case !visited[j]:
+				res.Polylines = append(res.Polylines, walk(i, j))
+			}
+		}
+	}
+
+	// Step 4: whatever is left are closed loops without nodes.
+	for i, on := range g.px {
+		if !on || node[i] != 0 || visited[i] {
+			continue
+		}
+		visited[i] = true
+		pl := Polyline{Points: []image.Point{g.point(i)}}
+		for cur := i; ; {
+			next := -1
+			for _, o := range offs {
+				if c := cur + o; g.px[c] && !visited[c] {
+					next = c
+					break
+				}
+			}
+			if next < 0 {
+				break
+			}
+			visited[next] = true
+			pl.Points = append(pl.Points, g.point(next))
+			cur = next
+		}
+		pl.Points = append(pl.Points, g.point(i))
+		res.Polylines = append(res.Polylines, pl)
+	}
+
+	for _, pl := range res.Polylines {
+		if pl.From > 0 {
+			res.Nodes[pl.From-1].Degree++
+		}
+		if pl.To > 0 {
+			res.Nodes[pl.To-1].Degree++
+		}
+	}
+	return res, ctx.Err()
+}
+
+// DrawSkeletonGraph draws the polylines in red, end points in blue and
+// junctions in green on a white background.
+func DrawSkeletonGraph(ctx context.Context, bounds image.Rectangle, sg *SkeletonGraph) (image.Image, error) {
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, dst.Bounds(), image.White, image.Point{}, draw.Src)
+
+	lineColor := color.RGBA{R: 255, A: 255}
+	for _, pl := range sg.Polylines {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for _, p := range pl.Points {
+			if p.In(bounds) {
+				dst.Set(p.X, p.Y, lineColor)
+			}
+		}
+	}
+
+	for _, n := range sg.Nodes {
+		c := color.RGBA{B: 255, A: 255}
+		if n.Kind == Junction {
+			c = color.RGBA{G: 160, A: 255}
+		}
+		r := image.Rect(n.Point.X-1, n.Point.Y-1, n.Point.X+2, n.Point.Y+2).Intersect(bounds)
+		draw.Draw(dst, r, image.NewUniform(c), image.Point{}, draw.Src)
+	}
+	return dst, ctx.Err()
+}
@@ -0,0 +1,87 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"testing"
+)
+
+// thickCross returns a 41x41 image with a black 5-pixel-wide "+" in it.
+func thickCross() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 41, 41))
+	for y := 0; y < 41; y++ {
+		for x := 0; x < 41; x++ {
+			v := uint8(255)
+			if x >= 4 && x <= 36 && y >= 18 && y <= 22 || y >= 4 && y <= 36 && x >= 18 && x <= 22 {
+				v = 0
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+func TestSkeletonGraph(t *testing.T) {
+	ctx := context.Background()
+	for _, m := range []ThinningMethod{ZhangSuen, GuoHall} {
+		skel, err := Skeletonize(ctx, thickCross(), m)
+		if err != nil {
+			t.Fatalf("%s: got error while thinning:\n%s", m, err.Error())
+		}
+
+		// one pixel wide: no 2x2 block of skeleton pixels
+		for y := 0; y < 40; y++ {
+			for x := 0; x < 40; x++ {
+				if isBlack(skel, x, y) && isBlack(skel, x+1, y) && isBlack(skel, x, y+1) && isBlack(skel, x+1, y+1) {
+					t.Fatalf("%s: skeleton is thicker than one pixel at (%d,%d)", m, x, y)
+				}
+			}
+		}
+
+		g, err := SkeletonToGraph(ctx, skel)
+		if err != nil {
+			t.Fatalf("%s: got error while building graph:\n%s", m, err.Error())
+		}
+		ends, junctions := 0, 0
+		for _, n := range g.Nodes {
+			switch n.Kind {
+			case EndPoint:
+				ends++
+			case Junction:
+				junctions++
+				if n.Degree != 4 {
+					t.Fatalf("%s: junction degree %d, want 4", m, n.Degree)
+				}
+			}
+		}
+		if ends != 4 || junctions != 1 || len(g.Polylines) != 4 {
+			t.Fatalf("%s: got %d ends, %d junctions, %d polylines; want 4, 1, 4",
+				m, ends, junctions, len(g.Polylines))
+		}
+	}
+}
+
+func TestSkeletonLoop(t *testing.T) {
+	ctx := context.Background()
+	ring := binaryImage(
+		"..........",
+		".########.",
+		".#......#.",
+		".#......#.",
+		".#......#.",
+		".########.",
+		"..........",
+	)
+	g, err := SkeletonToGraph(ctx, ring)
+	if err != nil {
+		t.Fatalf("got error while building graph:\n%s", err.Error())
+	}
+	if len(g.Nodes) != 0 || len(g.Polylines) != 1 {
+		t.Fatalf("got %d nodes and %d polylines, want one closed loop", len(g.Nodes), len(g.Polylines))
+	}
+	pl := g.Polylines[0]
+	if pl.Points[0] != pl.Points[len(pl.Points)-1] || len(pl.Points) != 23 {
+		t.Fatalf("loop is not closed or misses pixels: %v", pl.Points)
+	}
+}
@@ -0,0 +1,130 @@
+package imageutil
+
+import (
+	"bufio"
+	"context"
+	"encoding/base64"
+	"fmt"
+	"image"
+	"image/png"
+	"io"
+)
+
+// -----------------------------------------------------------------------------
+// SVG contour export
+// -----------------------------------------------------------------------------
+
+// SVGOptions - settings for WriteContoursSVG.
+type SVGOptions struct {
+	Background image.Image   // embedded under the contours as a PNG layer, if set
+	Smooth     bool          // trace with Bezier curves instead of the pixel polygon
+	Vector     VectorOptions // curve fitting settings when Smooth is set
+}
+
+// WriteContoursSVG writes the contours as an SVG drawing of the given size.
+// Every contour becomes one <path> whose id and data-* attributes carry its
+// metrics; each object is a <g> holding its outline, its holes and the
+// objects inside those holes, following the contour hierarchy.
+func WriteContoursSVG(ctx context.Context, w io.Writer, bounds image.Rectangle, contours []Contour, opts SVGOptions) error {
+	ms, err := MeasureContours(ctx, contours)
+	if err != nil {
+		return err
+	}
+	var paths []Path
+	if opts.Smooth {
+		if paths, err = VectorizeContours(ctx, contours, opts.Vector); err != nil {
+			return err
+		}
+	}
+
+	children := make(map[int][]int) // parent ID -> indices of its contours
+	for i, c := range contours {
+		children[c.Parent] = append(children[c.Parent], i)
+	}
+
+	bw := bufio.NewWriter(w)
+	fmt.Fprintf(bw, "<svg xmlns=\"http://www.w3.org/2000/svg\" xmlns:xlink=\"http://www.w3.org/1999/xlink\" "+
+		"width=\"%d\" height=\"%d\" viewBox=\"%d %d %d %d\">\n",
+		bounds.Dx(), bounds.Dy(), bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy())
+
+	if opts.Background != nil {
+		if err := writeSVGImage(bw, opts.Background); err != nil {
+			return err
+		}
+	}
+
+	bw.WriteString("  <g id=\"contours\" fill=\"none\" stroke-width=\"1\">\n")
+	var walk func(parent int, depth int) error
+	walk = func(parent int, depth int) error {
+		for _, i := range children[parent] {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			c, m := contours[i], ms[i]
+			indent := fmt.Sprintf("%*s", 2*depth+4, "")
+			if !c.Hole {
+				fmt.Fprintf(bw, "%s<g id=\"object-%d\">\n", indent, c.ID)
+			}
+
+			var d string
+			if opts.Smooth {
+				d = paths[i].SVGData()
+			} else {
+				d = polygonPath(c.Points).SVGData()
+			}
+			class, stroke := "outer", "red"
+			if c.Hole {
+				class, stroke = "hole", "blue"
+			}
+			fmt.Fprintf(bw, "%s  <path id=\"contour-%d\" class=\"%s\" stroke=\"%s\" data-parent=\"%d\" data-points=\"%d\" "+
+				"data-area=\"%s\" data-perimeter=\"%s\" data-circularity=\"%s\" data-bbox=\"%d %d %d %d\" "+
+				"data-shape=\"%s\" d=\"%s\"/>\n",
+				indent, c.ID, class, stroke, c.Parent, m.Points,
+				svgNum(m.Area), svgNum(m.Perimeter), svgNum(m.Circularity), m.X, m.Y, m.Width, m.Height,
+				Shape{Kind: m.Shape, Vertices: m.Vertices}, d)
+
+			if err := walk(c.ID, depth+1); err != nil {
+				return err
+			}
+			if !c.Hole {
+				fmt.Fprintf(bw, "%s</g>\n", indent)
+			}
+		}
+		return nil
+	}
+	if err := walk(0, 0); err != nil {
+		return err
+	}
+	bw.WriteString("  </g>\n</svg>\n")
+	return bw.Flush()
+}
+
+// polygonPath returns the closed polygon through the centres of the pixels.
+func polygonPath(points []image.Point) Path {
+	var p Path
+	if len(points) == 0 {
+		return p
+	}
+	centre := func(q image.Point) PointF { return Pf(q).Add(PointF{0.5, 0.5}) }
+	p.Start = centre(points[0])
+	for _, q := range points[1:] {
+		p.Segments = append(p.Segments, PathSegment{Line: true, P: centre(q)})
+	}
+	return p
+}
+
+// writeSVGImage embeds img as a base64 PNG <image> layer.
+func writeSVGImage(w *bufio.Writer, img image.Image) error {
+	b := img.Bounds()
+	fmt.Fprintf(w, "  <g id=\"background\">\n    <image x=\"%d\" y=\"%d\" width=\"%d\" height=\"%d\" xlink:href=\"data:image/png;base64,",
+		b.Min.X, b.Min.Y, b.Dx(), b.Dy())
+	enc := base64.NewEncoder(base64.StdEncoding, w)
+	if err := png.Encode(enc, img); err != nil {
+		return err
+	}
+	if err := enc.Close(); err != nil {
+		return err
+	}
+	_, err := w.WriteString("\"/>\n  </g>\n")
+	return err
+}
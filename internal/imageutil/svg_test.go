@@ -0,0 +1,91 @@
+package imageutil
+
+import (
+	"bytes"
+	"context"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"testing"
+)
+
+func TestWriteContoursSVG(t *testing.T) {
+	// ring with a dot inside its hole
+	mask := filledShape(func(p PointF) bool {
+		d := p.Dist(PointF{100, 100})
+		return (d < 70 && d > 40) || d < 15
+	})
+	contours, err := FindContours(context.Background(), mask)
+	if err != nil {
+		t.Fatalf("got error while finding contours:\n%s", err.Error())
+	}
+	if len(contours) != 3 {
+		t.Fatalf("got %d contours, want 3", len(contours))
+	}
+
+	for _, opts := range []SVGOptions{{}, {Background: mask, Smooth: true, Vector: DefaultVectorOptions}} {
+		var buf bytes.Buffer
+		if err := WriteContoursSVG(context.Background(), &buf, mask.Bounds(), contours, opts); err != nil {
+			t.Fatalf("got error while writing SVG:\n%s", err.Error())
+		}
+
+		// every path sits one group deeper than its parent contour
+		depth, paths, images := 0, map[string]int{}, 0
+		dec := xml.NewDecoder(&buf)
+		for {
+			tok, err := dec.Token()
+			if err == io.EOF {
+				break
+			}
+			if err != nil {
+				t.Fatalf("got error while parsing SVG:\n%s", err.Error())
+			}
+			switch e := tok.(type) {
+			case xml.StartElement:
+				switch e.Name.Local {
+				case "g":
+					depth++
+				case "image":
+					images++
+				case "path":
+					var id, area string
+					for _, a := range e.Attr {
+						switch a.Name.Local {
+						case "id":
+							id = a.Value
+						case "data-area":
+							area = a.Value
+						}
+					}
+					if area == "" || area == "0" {
+						t.Errorf("%s: missing data-area", id)
+					}
+					paths[id] = depth
+				}
+			case xml.EndElement:
+				if e.Name.Local == "g" {
+					depth--
+				}
+			}
+		}
+
+		if len(paths) != 3 {
+			t.Fatalf("got paths %v, want 3", paths)
+		}
+		// contours group and ring group; the hole shares the ring's group,
+		// the dot has its own inside it
+		for _, c := range contours {
+			id := fmt.Sprintf("contour-%d", c.ID)
+			want := 2
+			if !c.Hole && c.Parent != 0 {
+				want = 3
+			}
+			if paths[id] != want {
+				t.Errorf("%s: got depth %d, want %d", id, paths[id], want)
+			}
+		}
+		if wantImages := map[bool]int{false: 0, true: 1}[opts.Background != nil]; images != wantImages {
+			t.Errorf("got %d background images, want %d", images, wantImages)
+		}
+	}
+}
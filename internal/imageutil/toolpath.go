@@ -0,0 +1,297 @@
+package imageutil
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"image"
+	"image/color"
+	"image/draw"
+	"io"
+	"math"
+	"strconv"
+)
+
+// -----------------------------------------------------------------------------
+// Toolpaths
+// -----------------------------------------------------------------------------
+
+// ToolpathOptions - settings for BuildToolpath.
+type ToolpathOptions struct {
+	Scale    float64 // millimetres per pixel
+	Simplify float64 // Douglas-Peucker tolerance in pixels, 0 keeps every pixel
+}
+
+// DefaultToolpathOptions - 0.1 mm per pixel, straight runs merged.
+var DefaultToolpathOptions = ToolpathOptions{Scale: 0.1, Simplify: 0.5}
+
+const twoOptPasses = 20 // improvement rounds before 2-opt gives up
+
+// Toolpath - closed loops in machine millimetres (y up, origin at the
+// bottom left of the image) in cutting order. Every loop starts and ends
+// at the same point.
+type Toolpath struct {
+	Home  PointF // where the tool starts and returns to
+	Loops [][]PointF
+}
+
+// BuildToolpath turns every contour into a closed loop in millimetres and
+// orders the loops to keep travel moves short. Loops inside others are cut
+// first, so no part comes loose before its holes and inner parts are done.
+func BuildToolpath(ctx context.Context, bounds image.Rectangle, contours []Contour, opts ToolpathOptions) (Toolpath, error) {
+	var loops [][]PointF
+	contourParent := make(map[int]int, len(contours)) // contour ID -> parent ID
+	loopOf := make(map[int]int, len(contours))        // contour ID -> index in loops
+	var loopIDs []int
+	for _, c := range contours {
+		if err := ctx.Err(); err != nil {
+			return Toolpath{}, err
+		}
+		contourParent[c.ID] = c.Parent
+		pts := toPointsF(c.Points)
+		if opts.Simplify > 0 {
+			pts = ApproxPolygon(pts, opts.Simplify)
+		}
+		if len(pts) < 2 {
+			continue
+		}
+		loop := make([]PointF, 0, len(pts)+1)
+		for _, p := range pts {
+			loop = append(loop, PointF{
+				(p.X + 0.5 - float64(bounds.Min.X)) * opts.Scale,
+				(float64(bounds.Max.Y) - p.Y - 0.5) * opts.Scale,
+			})
+		}
+		loopOf[c.ID] = len(loops)
+		loopIDs = append(loopIDs, c.ID)
+		loops = append(loops, append(loop, loop[0]))
+	}
+
+	// the enclosing loop of each loop, skipping contours too small to cut
+	parent := make([]int, len(loops))
+	for i, id := range loopIDs {
+		parent[i] = -1
+		for p := contourParent[id]; p != 0; p = contourParent[p] {
+			if j, ok := loopOf[p]; ok {
+				parent[i] = j
+				break
+			}
+		}
+	}
+
+	tp := Toolpath{Loops: loops}
+	tp.Loops = orderLoops(tp.Home, tp.Loops, parent)
+	return tp, ctx.Err()
+}
+
+// orderLoops sorts the loops by nearest neighbour from home, entering each
+// at its point closest to the tool, then improves the tour with 2-opt.
+// parent gives the index of the loop enclosing each loop, or -1; a loop
+// only comes after every loop inside it.
+func orderLoops(home PointF, loops [][]PointF, parent []int) [][]PointF {
+	inside := make([]int, len(loops)) // loops inside each loop still to cut
+	for _, p := range parent {
+		if p >= 0 {
+			inside[p]++
+		}
+	}
+
+	order := make([]int, 0, len(loops))
+	rotated := make([][]PointF, len(loops))
+	done := make([]bool, len(loops))
+	at := home
+	for range loops {
+		best, bestIdx, bestDist := -1, 0, math.Inf(1)
+		for i, l := range loops {
+			if done[i] || inside[i] > 0 {
+				continue
+			}
+			for j, p := range l[:len(l)-1] {
+				if d := p.Dist(at); d < bestDist {
+					best, bestIdx, bestDist = i, j, d
+				}
+			}
+		}
+		done[best] = true
+		if p := parent[best]; p >= 0 {
+			inside[p]--
+		}
+		rotated[best] = rotateLoop(loops[best], bestIdx)
+		order = append(order, best)
+		at = rotated[best][0]
+	}
+
+	// 2-opt over the entry points: reversing a run of closed loops only
+	// changes the two travel moves at its ends. A run may only be reversed
+	// if none of its loops encloses another one of it.
+	entry := func(i int) PointF {
+		if i < 0 || i >= len(order) {
+			return home
+		}
+		return rotated[order[i]][0]
+	}
+	for range twoOptPasses {
+		improved := false
+		for i := 0; i < len(order)-1; i++ {
+			enclosing := map[int]bool{} // loops enclosing any loop of the run
+			for k := parent[order[i]]; k >= 0; k = parent[k] {
+				enclosing[k] = true
+			}
+			for j := i + 1; j < len(order); j++ {
+				if enclosing[order[j]] {
+					break // so does every longer run
+				}
+				for k := parent[order[j]]; k >= 0 && !enclosing[k]; k = parent[k] {
+					enclosing[k] = true
+				}
+				a, b := entry(i-1), entry(i)
+				c, d := entry(j), entry(j+1)
+				if a.Dist(c)+b.Dist(d) < a.Dist(b)+c.Dist(d)-1e-9 {
+					for l, r := i, j; l < r; l, r = l+1, r-1 {
+						order[l], order[r] = order[r], order[l]
+					}
+					improved = true
+				}
+			}
+		}
+		if !improved {
+			break
+		}
+	}
+
+	out := make([][]PointF, len(order))
+	for i, k := range order {
+		out[i] = rotated[k]
+	}
+	return out
+}
+
+// rotateLoop returns the closed loop starting (and ending) at index k.
+func rotateLoop(loop []PointF, k int) []PointF {
+	ring := loop[:len(loop)-1]
+	out := make([]PointF, 0, len(loop))
+	out = append(out, ring[k:]...)
+	out = append(out, ring[:k]...)
+	return append(out, ring[k])
+}
+
+// TravelLength returns the distance moved with the tool lifted, including
+// the way back home.
+func (tp Toolpath) TravelLength() float64 {
+	var d float64
+	at := tp.Home
+	for _, l := range tp.Loops {
+		d += at.Dist(l[0])
+		at = l[len(l)-1]
+	}
+	return d + at.Dist(tp.Home)
+}
+
+// -----------------------------------------------------------------------------
+// G-code and HPGL output
+// -----------------------------------------------------------------------------
+
+// MachineOptions - feeds and tool heights of the G-code and HPGL writers.
+type MachineOptions struct {
+	Feed    float64 // cutting feed, mm/min
+	Travel  float64 // travel feed, mm/min
+	ZUp     float64 // safe height for travel moves, mm
+	ZDown   float64 // cutting or drawing height, mm
+	PenUp   string  // G-code command lifting the pen; replaces the Z move if set
+	PenDown string  // G-code command lowering the pen; replaces the Z move if set
+}
+
+// DefaultMachineOptions - a slow pen plotter with Z heights.
+var DefaultMachineOptions = MachineOptions{Feed: 1000, Travel: 3000, ZUp: 5, ZDown: 0}
+
+func mm(v float64) string { return strconv.FormatFloat(math.Round(v*1000)/1000, 'f', -1, 64) }
+
+// WriteGCode writes the toolpath as G-code in absolute millimetres.
+func WriteGCode(w io.Writer, tp Toolpath, opts MachineOptions) error {
+	bw := bufio.NewWriter(w)
+	up := func() {
+		if opts.PenUp != "" {
+			fmt.Fprintln(bw, opts.PenUp)
+		} else {
+			fmt.Fprintf(bw, "G0 Z%s\n", mm(opts.ZUp))
+		}
+	}
+	down := func() {
+		if opts.PenDown != "" {
+			fmt.Fprintln(bw, opts.PenDown)
+		} else {
+			fmt.Fprintf(bw, "G1 Z%s F%s\n", mm(opts.ZDown), mm(opts.Feed))
+		}
+	}
+
+	fmt.Fprintf(bw, "; %d loops, %s mm travel\n", len(tp.Loops), mm(tp.TravelLength()))
+	fmt.Fprintln(bw, "G21 ; millimetres")
+	fmt.Fprintln(bw, "G90 ; absolute positions")
+	up()
+	for _, l := range tp.Loops {
+		fmt.Fprintf(bw, "G0 X%s Y%s F%s\n", mm(l[0].X), mm(l[0].Y), mm(opts.Travel))
+		down()
+		fmt.Fprintf(bw, "G1 F%s\n", mm(opts.Feed))
+		for _, p := range l[1:] {
+			fmt.Fprintf(bw, "G1 X%s Y%s\n", mm(p.X), mm(p.Y))
+		}
+		up()
+	}
+	fmt.Fprintf(bw, "G0 X%s Y%s F%s\n", mm(tp.Home.X), mm(tp.Home.Y), mm(opts.Travel))
+	fmt.Fprintln(bw, "M2")
+	return bw.Flush()
+}
+
+const hpglUnitsPerMM = 40
+
+// WriteHPGL writes the toolpath as HPGL with pen 1, moving at the cutting
+// feed (VS, in cm/s).
+func WriteHPGL(w io.Writer, tp Toolpath, opts MachineOptions) error {
+	bw := bufio.NewWriter(w)
+	pu := func(p PointF) string {
+		return fmt.Sprintf("%d,%d", int(math.Round(p.X*hpglUnitsPerMM)), int(math.Round(p.Y*hpglUnitsPerMM)))
+	}
+	fmt.Fprintf(bw, "IN;SP1;VS%s;\n", mm(opts.Feed/600))
+	for _, l := range tp.Loops {
+		fmt.Fprintf(bw, "PU%s;\n", pu(l[0]))
+		bw.WriteString("PD")
+		for i, p := range l[1:] {
+			if i > 0 {
+				bw.WriteString(",")
+			}
+			bw.WriteString(pu(p))
+		}
+		bw.WriteString(";\n")
+	}
+	fmt.Fprintf(bw, "PU%s;SP0;\n", pu(tp.Home))
+	return bw.Flush()
+}
+
+// DrawToolpath renders a preview of the toolpath over the image area it
+// was built from: cuts in black, travel moves as dashed red lines.
+func DrawToolpath(ctx context.Context, bounds image.Rectangle, tp Toolpath, opts ToolpathOptions) (image.Image, error) {
+	dst := image.NewRGBA(bounds)
+	draw.Draw(dst, bounds, image.White, image.Point{}, draw.Src)
+	// back from millimetres to pixels
+	px := func(p PointF) image.Point {
+		return PointF{
+			p.X/opts.Scale - 0.5 + float64(bounds.Min.X),
+			float64(bounds.Max.Y) - p.Y/opts.Scale - 0.5,
+		}.Round()
+	}
+	cut := color.RGBA{A: 255}
+	travel := color.RGBA{R: 220, A: 255}
+	at := tp.Home
+	for _, l := range tp.Loops {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		for i := 1; i < len(l); i++ {
+			drawLine(dst, px(l[i-1]), px(l[i]), cut)
+		}
+		drawDashed(dst, px(at), px(l[0]), travel, 4)
+		at = l[len(l)-1]
+	}
+	drawDashed(dst, px(at), px(tp.Home), travel, 4)
+	return dst, ctx.Err()
+}
@@ -0,0 +1,150 @@
+package imageutil
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"image/color"
+	"strings"
+	"testing"
+)
+
+func TestOrderLoops(t *testing.T) {
+	// small squares along the x axis in shuffled order: the best tour goes
+	// out along the row and comes back
+	var loops [][]PointF
+	for _, x := range []float64{70, 10, 50, 90, 30, 0, 80, 20, 60, 40} {
+		loops = append(loops, []PointF{{x, 0}, {x + 1, 0}, {x + 1, 1}, {x, 1}, {x, 0}})
+	}
+	parent := []int{-1, -1, -1, -1, -1, -1, -1, -1, -1, -1}
+	tp := Toolpath{Home: PointF{-10, 0}, Loops: orderLoops(PointF{-10, 0}, loops, parent)}
+	if len(tp.Loops) != len(loops) {
+		t.Fatalf("got %d loops, want %d", len(tp.Loops), len(loops))
+	}
+	for _, l := range tp.Loops {
+		if l[0] != l[len(l)-1] || len(l) != 5 {
+			t.Errorf("loop %v is not closed", l)
+		}
+	}
+	// out to x=90 and back, plus up to one unit of detours per loop
+	if d := tp.TravelLength(); d > 2*100+float64(len(loops)) {
+		t.Errorf("got travel %.1f, want about %d", d, 2*100)
+	}
+}
+
+func TestOrderLoopsInsideOut(t *testing.T) {
+	square := func(x, y, size float64) []PointF {
+		return []PointF{{x, y}, {x + size, y}, {x + size, y + size}, {x, y + size}, {x, y}}
+	}
+	// two parts with a hole each, a third part inside the first hole and a
+	// loose square; the part corners are nearest to home
+	loops := [][]PointF{
+		square(0, 0, 100),   // 0: part
+		square(10, 10, 60),  // 1: hole in 0
+		square(20, 20, 20),  // 2: part in 1
+		square(110, 0, 50),  // 3: part
+		square(120, 10, 30), // 4: hole in 3
+		square(-5, 0, 2),    // 5: loose
+	}
+	parent := []int{-1, 0, 1, -1, 3, -1}
+	out := orderLoops(PointF{-10, 0}, loops, parent)
+	if len(out) != len(loops) {
+		t.Fatalf("got %d loops, want %d", len(out), len(loops))
+	}
+
+	// which input loop each output loop is, by its bounding corner
+	pos := make([]int, len(loops))
+	for i, l := range out {
+		lo := l[0]
+		for _, p := range l {
+			lo = PointF{min(lo.X, p.X), min(lo.Y, p.Y)}
+		}
+		for k, in := range loops {
+			if in[0] == lo {
+				pos[k] = i
+			}
+		}
+	}
+	for k, p := range parent {
+		if p >= 0 && pos[k] > pos[p] {
+			t.Errorf("loop %d cut at %d after its enclosing loop %d at %d", k, pos[k], p, pos[p])
+		}
+	}
+}
+
+func TestToolpathOutput(t *testing.T) {
+	mask := image.NewGray(image.Rect(0, 0, 100, 50))
+	for y := range 50 {
+		for x := range 100 {
+			v := uint8(255)
+			if (x >= 10 && x < 30 || x >= 60 && x < 90) && y >= 10 && y < 40 {
+				v = 0
+			}
+			mask.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	contours, err := FindContours(context.Background(), mask)
+	if err != nil {
+		t.Fatalf("got error while finding contours:\n%s", err.Error())
+	}
+	opts := ToolpathOptions{Scale: 0.5, Simplify: 0.5}
+	tp, err := BuildToolpath(context.Background(), mask.Bounds(), contours, opts)
+	if err != nil {
+		t.Fatalf("got error while building toolpath:\n%s", err.Error())
+	}
+	if len(tp.Loops) != 2 || len(tp.Loops[0]) != 5 {
+		t.Fatalf("got loops %v, want 2 rectangles", tp.Loops)
+	}
+	// first rectangle: pixels 10..29 x 10..39, centres in mm with y up
+	for _, p := range tp.Loops[0] {
+		if (p.X != 5.25 && p.X != 14.75) || (p.Y != 5.25 && p.Y != 19.75) {
+			t.Errorf("unexpected corner %v", p)
+		}
+	}
+
+	var buf bytes.Buffer
+	if err := WriteGCode(&buf, tp, DefaultMachineOptions); err != nil {
+		t.Fatalf("got error while writing G-code:\n%s", err.Error())
+	}
+	g := buf.String()
+	if !strings.Contains(g, "G21") || strings.Count(g, "G1 Z0 F1000") != 2 || strings.Count(g, "G0 Z5") != 3 {
+		t.Errorf("unexpected G-code:\n%s", g)
+	}
+	buf.Reset()
+	pen := DefaultMachineOptions
+	pen.PenUp, pen.PenDown = "M5", "M3 S90"
+	if err := WriteGCode(&buf, tp, pen); err != nil {
+		t.Fatalf("got error while writing G-code:\n%s", err.Error())
+	}
+	if g := buf.String(); strings.Contains(g, " Z") || strings.Count(g, "M3 S90") != 2 {
+		t.Errorf("unexpected pen G-code:\n%s", g)
+	}
+
+	buf.Reset()
+	if err := WriteHPGL(&buf, tp, DefaultMachineOptions); err != nil {
+		t.Fatalf("got error while writing HPGL:\n%s", err.Error())
+	}
+	if h := buf.String(); !strings.HasPrefix(h, "IN;SP1;") || strings.Count(h, "PD") != 2 || !strings.Contains(h, "PU210,210;") {
+		t.Errorf("unexpected HPGL:\n%s", h)
+	}
+
+	img, err := DrawToolpath(context.Background(), mask.Bounds(), tp, opts)
+	if err != nil {
+		t.Fatalf("got error while drawing toolpath:\n%s", err.Error())
+	}
+	var cut, travel int
+	for y := range 50 {
+		for x := range 100 {
+			r, g, _, _ := img.At(x, y).RGBA()
+			switch {
+			case r == 0 && g == 0:
+				cut++
+			case r > 0 && g == 0:
+				travel++
+			}
+		}
+	}
+	if cut < 150 || travel == 0 {
+		t.Errorf("got %d cut and %d travel pixels", cut, travel)
+	}
+}
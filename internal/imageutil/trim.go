@@ -0,0 +1,133 @@
+package imageutil
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"image"
+	"image/draw"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Auto-trim
+// -----------------------------------------------------------------------------
+
+// ErrNoObjects - the mask contains no object to work with.
+var ErrNoObjects = errors.New("no objects found")
+
+// TrimOptions - settings for Trim.
+type TrimOptions struct {
+	Largest bool    // crop to the largest object instead of the union of all
+	Padding int     // pixels added on every side
+	Aspect  float64 // width / height of the crop, 0 - keep the objects' own
+}
+
+// ParseAspect parses an aspect ratio given as "w:h" or as a single number.
+// An empty string means no aspect ratio.
+func ParseAspect(s string) (float64, error) {
+	if s == "" {
+		return 0, nil
+	}
+	w, h, ok := strings.Cut(s, ":")
+	if !ok {
+		h = "1"
+	}
+	a, errA := strconv.ParseFloat(strings.TrimSpace(w), 64)
+	b, errB := strconv.ParseFloat(strings.TrimSpace(h), 64)
+	if errA != nil || errB != nil || a <= 0 || b <= 0 {
+		return 0, fmt.Errorf("invalid aspect ratio %q (want w:h or a positive number)", s)
+	}
+	return a / b, nil
+}
+
+// TrimBounds returns the crop rectangle around the objects of bin: the
+// bounding box of all outer contours (or of the largest one), padded and
+// widened to the requested aspect ratio, kept inside the image.
+func TrimBounds(ctx context.Context, bin image.Image, opts TrimOptions) (image.Rectangle, error) {
+	contours, err := FindContours(ctx, bin)
+	if err != nil {
+		return image.Rectangle{}, err
+	}
+
+	var r image.Rectangle
+	bestArea := -1.0
+	for _, c := range contours {
+		if c.Hole {
+			continue
+		}
+		box := pointsBounds(c.Points)
+		if !opts.Largest {
+			r = r.Union(box)
+			continue
+		}
+		if a := PolygonArea(toPointsF(c.Points)); a > bestArea {
+			r, bestArea = box, a
+		}
+	}
+	if r.Empty() {
+		return image.Rectangle{}, ErrNoObjects
+	}
+
+	r = r.Inset(-opts.Padding)
+	if opts.Aspect > 0 {
+		r = widenToAspect(r, opts.Aspect)
+	}
+	return fitInside(r, bin.Bounds()), nil
+}
+
+// Trim crops src to TrimBounds of its mask bin. The result starts at (0, 0).
+func Trim(ctx context.Context, src, bin image.Image, opts TrimOptions) (image.Image, error) {
+	r, err := TrimBounds(ctx, bin, opts)
+	if err != nil {
+		return nil, err
+	}
+	out := image.NewRGBA(image.Rect(0, 0, r.Dx(), r.Dy()))
+	draw.Draw(out, out.Bounds(), src, r.Min, draw.Src)
+	return out, ctx.Err()
+}
+
+// pointsBounds returns the smallest rectangle containing the pixels pts.
+func pointsBounds(pts []image.Point) image.Rectangle {
+	r := image.Rectangle{Min: pts[0], Max: pts[0].Add(image.Pt(1, 1))}
+	for _, p := range pts[1:] {
+		r = r.Union(image.Rectangle{Min: p, Max: p.Add(image.Pt(1, 1))})
+	}
+	return r
+}
+
+// widenToAspect grows the short side of r around its centre until
+// width / height equals aspect.
+func widenToAspect(r image.Rectangle, aspect float64) image.Rectangle {
+	w, h := float64(r.Dx()), float64(r.Dy())
+	if w/h < aspect {
+		grow := int(math.Round(h*aspect)) - r.Dx()
+		r.Min.X -= grow / 2
+		r.Max.X += grow - grow/2
+	} else {
+		grow := int(math.Round(w/aspect)) - r.Dy()
+		r.Min.Y -= grow / 2
+		r.Max.Y += grow - grow/2
+	}
+	return r
+}
+
+// fitInside shifts r back inside bounds where it sticks out, and clips it
+// only where it is larger than bounds.
+func fitInside(r, bounds image.Rectangle) image.Rectangle {
+	if d := bounds.Min.X - r.Min.X; d > 0 {
+		r = r.Add(image.Pt(d, 0))
+	}
+	if d := r.Max.X - bounds.Max.X; d > 0 {
+		r = r.Sub(image.Pt(d, 0))
+	}
+	if d := bounds.Min.Y - r.Min.Y; d > 0 {
+		r = r.Add(image.Pt(0, d))
+	}
+	if d := r.Max.Y - bounds.Max.Y; d > 0 {
+		r = r.Sub(image.Pt(0, d))
+	}
+	return r.Intersect(bounds)
+}
@@ -0,0 +1,59 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"testing"
+)
+
+func TestTrimBounds(t *testing.T) {
+	ctx := context.Background()
+	bin := binaryImage(
+		"....................",
+		"..###...............",
+		"..###...............",
+		"..........######....",
+		"..........######....",
+		"..........######....",
+		"..........######....",
+		"....................",
+	)
+
+	tests := []struct {
+		name string
+		opts TrimOptions
+		want image.Rectangle
+	}{
+		{"union", TrimOptions{}, image.Rect(2, 1, 16, 7)},
+		{"largest", TrimOptions{Largest: true}, image.Rect(10, 3, 16, 7)},
+		{"padding", TrimOptions{Largest: true, Padding: 1}, image.Rect(9, 2, 17, 8)},
+		{"square", TrimOptions{Largest: true, Aspect: 1}, image.Rect(10, 2, 16, 8)},
+		{"clamped", TrimOptions{Padding: 3}, image.Rect(0, 0, 20, 8)},
+		{"shifted", TrimOptions{Largest: true, Aspect: 4}, image.Rect(4, 3, 20, 7)},
+	}
+	for _, tt := range tests {
+		got, err := TrimBounds(ctx, bin, tt.opts)
+		if err != nil {
+			t.Fatalf("got error while trimming:\n%s", err.Error())
+		}
+		if got != tt.want {
+			t.Fatalf("%s: got %v, want %v", tt.name, got, tt.want)
+		}
+	}
+
+	if _, err := TrimBounds(ctx, binaryImage("....", "...."), TrimOptions{}); err != ErrNoObjects {
+		t.Fatalf("got error %v for an empty mask, want ErrNoObjects", err)
+	}
+}
+
+func TestParseAspect(t *testing.T) {
+	for s, want := range map[string]float64{"": 0, "16:9": 16.0 / 9, "1.5": 1.5, " 4 : 3 ": 4.0 / 3} {
+		got, err := ParseAspect(s)
+		if err != nil || got != want {
+			t.Fatalf("ParseAspect(%q) = %v, %v; want %v", s, got, err, want)
+		}
+	}
+	if _, err := ParseAspect("3:0"); err == nil {
+		t.Fatalf("ParseAspect(\"3:0\") returned no error")
+	}
+}
@@ -0,0 +1,381 @@
+package imageutil
+
+import (
+	"context"
+	"fmt"
+	"image"
+	"io"
+	"math"
+	"strconv"
+	"strings"
+)
+
+// -----------------------------------------------------------------------------
+// Bezier vectorization
+// -----------------------------------------------------------------------------
+
+// VectorOptions - settings for VectorizeContour.
+type VectorOptions struct {
+	Tolerance   float64 // maximum distance of a contour point from the fitted path, pixels
+	CornerAngle float64 // angles (degrees) sharper than this become corners
+}
+
+// DefaultVectorOptions - defaults for VectorizeContour.
+var DefaultVectorOptions = VectorOptions{Tolerance: 1, CornerAngle: 135}
+
+const (
+	vectorCornerSpan   = 4 // points on each side used to measure corner angles
+	vectorTangent      = 4 // points used to estimate end tangents
+	vectorNewtonIter   = 4 // reparametrisation rounds before splitting a curve
+	vectorSmoothPasses = 2 // smoothing passes over the contour before fitting
+)
+
+// PathSegment - one piece of a Path: a straight line or a cubic Bezier
+// curve ending at P.
+type PathSegment struct {
+	Line   bool
+	C1, C2 PointF // Bezier control points, unused for lines
+	P      PointF
+}
+
+// Path - closed outline made of lines and cubic Bezier curves.
+type Path struct {
+	ID       int
+	Parent   int
+	Hole     bool
+	Start    PointF
+	Segments []PathSegment
+}
+
+// VectorizeContours fits every contour with VectorizeContour. Coordinates
+// are moved to pixel centres.
+func VectorizeContours(ctx context.Context, contours []Contour, opts VectorOptions) ([]Path, error) {
+	paths := make([]Path, 0, len(contours))
+	for _, c := range contours {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		pts := toPointsF(c.Points)
+		for i := range pts {
+			pts[i] = pts[i].Add(PointF{0.5, 0.5})
+		}
+		p, err := VectorizeContour(pts, opts)
+		if err != nil {
+			return nil, err
+		}
+		p.ID, p.Parent, p.Hole = c.ID, c.Parent, c.Hole
+		paths = append(paths, p)
+	}
+	return paths, nil
+}
+
+// VectorizeContour turns a closed contour into lines and cubic Bezier
+// curves that stay within opts.Tolerance of every point. The contour is cut
+// at its corners; each piece is fitted with Schneider's algorithm, splitting
+// it where the error is largest until the fit is good enough. The
+// tolerance has to be positive.
+func VectorizeContour(pts []PointF, opts VectorOptions) (Path, error) {
+	if opts.Tolerance <= 0 {
+		return Path{}, fmt.Errorf("invalid vector tolerance %g", opts.Tolerance)
+	}
+	pts = dedupRing(pts)
+	if len(pts) < 4 {
+		p := Path{}
+		if len(pts) > 0 {
+			p.Start = pts[0]
+			for _, q := range append(pts[1:], pts[0]) {
+				p.Segments = append(p.Segments, PathSegment{Line: true, P: q})
+			}
+		}
+		return p, nil
+	}
+
+	n := len(pts)
+	corners := findCorners(KCosine(pts, vectorCornerSpan), vectorCornerSpan, opts.CornerAngle)
+	pts = smoothRing(pts, corners)
+	f := &curveFitter{tol: opts.Tolerance}
+	if len(corners) == 0 {
+		// smooth closed curve: two halves joined with shared tangents
+		half := n / 2
+		t0 := ringTangent(pts, 0)
+		th := ringTangent(pts, half)
+		f.fit(pts[:half+1], t0, th.Mul(-1))
+		f.fit(append(append([]PointF(nil), pts[half:]...), pts[0]), th, t0.Mul(-1))
+		return Path{Start: pts[0], Segments: f.segs}, nil
+	}
+
+	for i, c := range corners {
+		next := corners[(i+1)%len(corners)]
+		var piece []PointF
+		for j := c; ; j = (j + 1) % n {
+			piece = append(piece, pts[j])
+			if j == next && len(piece) > 1 {
+				break
+			}
+		}
+		// only whole edges between corners become lines; inside a curve a
+		// short straight run is pixel noise
+		if straight(piece, opts.Tolerance) {
+			f.segs = append(f.segs, PathSegment{Line: true, P: piece[len(piece)-1]})
+			continue
+		}
+		f.fit(piece, endTangent(piece, false), endTangent(piece, true))
+	}
+	return Path{Start: pts[corners[0]], Segments: f.segs}, nil
+}
+
+// dedupRing drops consecutive duplicate points, including a closing point
+// equal to the first.
+func dedupRing(pts []PointF) []PointF {
+	out := make([]PointF, 0, len(pts))
+	for _, p := range pts {
+		if len(out) == 0 || p != out[len(out)-1] {
+			out = append(out, p)
+		}
+	}
+	for len(out) > 1 && out[0] == out[len(out)-1] {
+		out = out[:len(out)-1]
+	}
+	return out
+}
+
+// smoothRing flattens the pixel staircase of the ring with two passes of a
+// 1-2-1 filter, leaving the corner points in place.
+func smoothRing(pts []PointF, corners []int) []PointF {
+	n := len(pts)
+	fixed := make([]bool, n)
+	for _, c := range corners {
+		fixed[c] = true
+	}
+	cur := pts
+	for range vectorSmoothPasses {
+		next := make([]PointF, n)
+		for i, p := range cur {
+			if fixed[i] {
+				next[i] = p
+				continue
+			}
+			next[i] = cur[(i-1+n)%n].Add(p.Mul(2)).Add(cur[(i+1)%n]).Mul(0.25)
+		}
+		cur = next
+	}
+	return cur
+}
+
+// ringTangent returns the unit direction of the ring at point i.
+func ringTangent(pts []PointF, i int) PointF {
+	n := len(pts)
+	d := pts[(i+vectorTangent)%n].Sub(pts[(i-vectorTangent+n)%n])
+	return unit(d)
+}
+
+// endTangent returns the unit tangent at the start of an open polyline
+// pointing into it, or at its end pointing back into it.
+func endTangent(pts []PointF, atEnd bool) PointF {
+	k := min(vectorTangent, len(pts)-1)
+	if atEnd {
+		return unit(pts[len(pts)-1-k].Sub(pts[len(pts)-1]))
+	}
+	return unit(pts[k].Sub(pts[0]))
+}
+
+func unit(p PointF) PointF {
+	if l := p.Len(); l > 0 {
+		return p.Mul(1 / l)
+	}
+	return p
+}
+
+// straight reports whether every point of pts lies within tol of the chord
+// between its ends.
+func straight(pts []PointF, tol float64) bool {
+	first, last := pts[0], pts[len(pts)-1]
+	for _, p := range pts[1 : len(pts)-1] {
+		if segmentDistance(p, first, last) > tol {
+			return false
+		}
+	}
+	return true
+}
+
+// curveFitter collects the segments of Schneider's recursive fit.
+type curveFitter struct {
+	tol  float64
+	segs []PathSegment
+}
+
+// fit appends segments approximating the open polyline pts. t1 and t2 are
+// the unit tangents at its ends, both pointing into the polyline.
+func (f *curveFitter) fit(pts []PointF, t1, t2 PointF) {
+	if len(pts) <= 2 {
+		// nothing left to split: join the ends
+		f.segs = append(f.segs, PathSegment{Line: true, P: pts[len(pts)-1]})
+		return
+	}
+	u := chordParams(pts)
+	bez := fitBezier(pts, u, t1, t2)
+	errMax, split := bezierError(pts, bez, u)
+	if errMax <= f.tol {
+		f.segs = append(f.segs, PathSegment{C1: bez[1], C2: bez[2], P: bez[3]})
+		return
+	}
+	if errMax <= 4*f.tol {
+		for range vectorNewtonIter {
+			u = reparametrize(pts, u, bez)
+			bez = fitBezier(pts, u, t1, t2)
+			if errMax, split = bezierError(pts, bez, u); errMax <= f.tol {
+				f.segs = append(f.segs, PathSegment{C1: bez[1], C2: bez[2], P: bez[3]})
+				return
+			}
+		}
+	}
+
+	split = min(max(split, 1), len(pts)-2)
+	tc := unit(pts[split-1].Sub(pts[split+1]))
+	f.fit(pts[:split+1], t1, tc)
+	f.fit(pts[split:], tc.Mul(-1), t2)
+}
+
+// chordParams assigns every point its relative arc length in [0, 1].
+func chordParams(pts []PointF) []float64 {
+	u := make([]float64, len(pts))
+	for i := 1; i < len(pts); i++ {
+		u[i] = u[i-1] + pts[i].Dist(pts[i-1])
+	}
+	for i := range u {
+		u[i] /= u[len(u)-1]
+	}
+	return u
+}
+
+// fitBezier finds the control point distances along t1 and t2 that fit
+// pts at parameters u best in the least-squares sense.
+func fitBezier(pts []PointF, u []float64, t1, t2 PointF) [4]PointF {
+	first, last := pts[0], pts[len(pts)-1]
+	var c00, c01, c11, x0, x1 float64
+	for i, p := range pts {
+		b0, b1, b2, b3 := bernstein(u[i])
+		a0, a1 := t1.Mul(b1), t2.Mul(b2)
+		c00 += a0.Dot(a0)
+		c01 += a0.Dot(a1)
+		c11 += a1.Dot(a1)
+		tmp := p.Sub(first.Mul(b0 + b1)).Sub(last.Mul(b2 + b3))
+		x0 += a0.Dot(tmp)
+		x1 += a1.Dot(tmp)
+	}
+
+	segLen := first.Dist(last)
+	alpha1, alpha2 := segLen/3, segLen/3
+	if det := c00*c11 - c01*c01; math.Abs(det) > 1e-12 {
+		a1, a2 := (x0*c11-x1*c01)/det, (c00*x1-c01*x0)/det
+		// negative or tiny distances make loops; fall back to the heuristic
+		if a1 > 1e-6*segLen && a2 > 1e-6*segLen {
+			alpha1, alpha2 = a1, a2
+		}
+	}
+	return [4]PointF{first, first.Add(t1.Mul(alpha1)), last.Add(t2.Mul(alpha2)), last}
+}
+
+func bernstein(t float64) (b0, b1, b2, b3 float64) {
+	s := 1 - t
+	return s * s * s, 3 * t * s * s, 3 * t * t * s, t * t * t
+}
+
+// bezierAt evaluates the cubic Bezier bez at t.
+func bezierAt(bez [4]PointF, t float64) PointF {
+	b0, b1, b2, b3 := bernstein(t)
+	return bez[0].Mul(b0).Add(bez[1].Mul(b1)).Add(bez[2].Mul(b2)).Add(bez[3].Mul(b3))
+}
+
+// bezierError returns the largest distance between pts and the curve at
+// their parameters, and the index of that point.
+func bezierError(pts []PointF, bez [4]PointF, u []float64) (float64, int) {
+	maxErr, split := 0.0, len(pts)/2
+	for i := 1; i < len(pts)-1; i++ {
+		if d := bezierAt(bez, u[i]).Dist(pts[i]); d > maxErr {
+			maxErr, split = d, i
+		}
+	}
+	return maxErr, split
+}
+
+// reparametrize improves every parameter with one Newton-Raphson step
+// towards the closest point of the curve.
+func reparametrize(pts []PointF, u []float64, bez [4]PointF) []float64 {
+	// first and second derivative control points
+	var d1 [3]PointF
+	for i := range d1 {
+		d1[i] = bez[i+1].Sub(bez[i]).Mul(3)
+	}
+	d2 := [2]PointF{d1[1].Sub(d1[0]).Mul(2), d1[2].Sub(d1[1]).Mul(2)}
+
+	out := make([]float64, len(u))
+	for i, t := range u {
+		s := 1 - t
+		q := bezierAt(bez, t).Sub(pts[i])
+		q1 := d1[0].Mul(s * s).Add(d1[1].Mul(2 * s * t)).Add(d1[2].Mul(t * t))
+		q2 := d2[0].Mul(s).Add(d2[1].Mul(t))
+		den := q1.Dot(q1) + q.Dot(q2)
+		out[i] = t
+		if den != 0 {
+			out[i] = math.Min(1, math.Max(0, t-q.Dot(q1)/den))
+		}
+	}
+	return out
+}
+
+// -----------------------------------------------------------------------------
+// SVG output
+// -----------------------------------------------------------------------------
+
+// SVGData returns the path as SVG path data ("M ... C ... L ... Z").
+func (p Path) SVGData() string {
+	var b strings.Builder
+	fmt.Fprintf(&b, "M%s,%s", svgNum(p.Start.X), svgNum(p.Start.Y))
+	for _, s := range p.Segments {
+		if s.Line {
+			fmt.Fprintf(&b, " L%s,%s", svgNum(s.P.X), svgNum(s.P.Y))
+			continue
+		}
+		fmt.Fprintf(&b, " C%s,%s %s,%s %s,%s",
+			svgNum(s.C1.X), svgNum(s.C1.Y), svgNum(s.C2.X), svgNum(s.C2.Y), svgNum(s.P.X), svgNum(s.P.Y))
+	}
+	b.WriteString(" Z")
+	return b.String()
+}
+
+// svgNum formats a coordinate with at most two decimals.
+func svgNum(v float64) string {
+	return strconv.FormatFloat(math.Round(v*100)/100, 'f', -1, 64)
+}
+
+// WriteVectorSVG writes the paths as a filled SVG drawing of the given
+// size: every object becomes one black <path> together with its holes,
+// using the even-odd fill rule.
+func WriteVectorSVG(w io.Writer, bounds image.Rectangle, paths []Path) error {
+	holes := make(map[int][]Path)
+	for _, p := range paths {
+		if p.Hole {
+			holes[p.Parent] = append(holes[p.Parent], p)
+		}
+	}
+
+	if _, err := fmt.Fprintf(w, "<svg xmlns=\"http://www.w3.org/2000/svg\" width=\"%d\" height=\"%d\" viewBox=\"%d %d %d %d\">\n",
+		bounds.Dx(), bounds.Dy(), bounds.Min.X, bounds.Min.Y, bounds.Dx(), bounds.Dy()); err != nil {
+		return err
+	}
+	for _, p := range paths {
+		if p.Hole {
+			continue
+		}
+		d := p.SVGData()
+		for _, h := range holes[p.ID] {
+			d += " " + h.SVGData()
+		}
+		if _, err := fmt.Fprintf(w, "  <path id=\"c%d\" fill=\"black\" fill-rule=\"evenodd\" d=\"%s\"/>\n", p.ID, d); err != nil {
+			return err
+		}
+	}
+	_, err := io.WriteString(w, "</svg>\n")
+	return err
+}
@@ -0,0 +1,116 @@
+package imageutil
+
+import (
+	"bytes"
+	"context"
+	"image"
+	"math"
+	"strings"
+	"testing"
+)
+
+// pathDistance returns the largest distance of pts from the sampled path.
+func pathDistance(p Path, pts []PointF) float64 {
+	var samples []PointF
+	cur := p.Start
+	for _, s := range p.Segments {
+		bez := [4]PointF{cur, s.C1, s.C2, s.P}
+		if s.Line {
+			bez[1], bez[2] = cur, s.P
+		}
+		for i := range 64 {
+			samples = append(samples, bezierAt(bez, float64(i)/64))
+		}
+		cur = s.P
+	}
+	worst := 0.0
+	for _, q := range pts {
+		best := math.Inf(1)
+		for i := range samples {
+			best = math.Min(best, segmentDistance(q, samples[i], samples[(i+1)%len(samples)]))
+		}
+		worst = math.Max(worst, best)
+	}
+	return worst
+}
+
+func TestVectorizeContour(t *testing.T) {
+	square := RotatedRect{Center: PointF{100, 100}, Axis: PointF{1, 0}, W: 100, H: 100}.Corners()
+	tests := []struct {
+		name   string
+		inside func(PointF) bool
+		lines  bool // only straight segments
+		curves bool // only Bezier curves
+		maxSeg int
+	}{
+		{"square", polygonShape(square[:]), true, false, 4},
+		{"circle", func(p PointF) bool { return p.Dist(PointF{100, 100}) < 70 }, false, true, 8},
+		{"triangle", polygonShape(regularPolygon(3, 80, 80, 0.3)), true, false, 3},
+	}
+	for _, tt := range tests {
+		c := shapeContour(t, tt.inside)
+		pts := toPointsF(c.Points)
+		p, err := VectorizeContour(pts, DefaultVectorOptions)
+		if err != nil {
+			t.Fatalf("%s: got error while vectorizing:\n%s", tt.name, err.Error())
+		}
+
+		if len(p.Segments) == 0 || len(p.Segments) > tt.maxSeg {
+			t.Errorf("%s: got %d segments, want 1..%d", tt.name, len(p.Segments), tt.maxSeg)
+		}
+		for _, s := range p.Segments {
+			if (tt.lines && !s.Line) || (tt.curves && s.Line) {
+				t.Errorf("%s: unexpected segment %+v", tt.name, s)
+				break
+			}
+		}
+		if last := p.Segments[len(p.Segments)-1].P; last != p.Start {
+			t.Errorf("%s: path ends at %v, want %v", tt.name, last, p.Start)
+		}
+		if d := pathDistance(p, pts); d > DefaultVectorOptions.Tolerance+0.5 {
+			t.Errorf("%s: contour is %.2f away from the path", tt.name, d)
+		}
+	}
+	circle := toPointsF(shapeContour(t, func(p PointF) bool { return p.Dist(PointF{100, 100}) < 70 }).Points)
+	if _, err := VectorizeContour(circle, VectorOptions{Tolerance: -1, CornerAngle: 135}); err == nil {
+		t.Errorf("got no error for a negative tolerance")
+	}
+	// a tolerance no curve meets splits down to single pixel steps
+	p, err := VectorizeContour(circle, VectorOptions{Tolerance: 1e-9, CornerAngle: 135})
+	if err != nil {
+		t.Fatalf("got error while vectorizing with a tiny tolerance:\n%s", err.Error())
+	}
+	if last := p.Segments[len(p.Segments)-1].P; last != p.Start {
+		t.Errorf("tiny tolerance: path ends at %v, want %v", last, p.Start)
+	}
+}
+
+func TestWriteVectorSVG(t *testing.T) {
+	ring := filledShape(func(p PointF) bool {
+		d := p.Dist(PointF{100, 100})
+		return d < 70 && d > 30
+	})
+	contours, err := FindContours(context.Background(), ring)
+	if err != nil {
+		t.Fatalf("got error while finding contours:\n%s", err.Error())
+	}
+	paths, err := VectorizeContours(context.Background(), contours, DefaultVectorOptions)
+	if err != nil {
+		t.Fatalf("got error while vectorizing contours:\n%s", err.Error())
+	}
+
+	var buf bytes.Buffer
+	if err := WriteVectorSVG(&buf, image.Rect(0, 0, 200, 200), paths); err != nil {
+		t.Fatalf("got error while writing SVG:\n%s", err.Error())
+	}
+	svg := buf.String()
+	if n := strings.Count(svg, "<path"); n != 1 {
+		t.Errorf("got %d paths, want the ring and its hole in one:\n%s", n, svg)
+	}
+	if n := strings.Count(svg, "M"); n != 2 {
+		t.Errorf("got %d subpaths, want 2:\n%s", n, svg)
+	}
+	if !strings.Contains(svg, `viewBox="0 0 200 200"`) || !strings.Contains(svg, " C") {
+		t.Errorf("unexpected SVG:\n%s", svg)
+	}
+}
@@ -0,0 +1,285 @@
+package imageutil
+
+import (
+	"container/heap"
+	"context"
+	"image"
+	"image/color"
+	"math"
+)
+
+// -----------------------------------------------------------------------------
+// Euclidean distance transform
+// -----------------------------------------------------------------------------
+
+// DistanceMap - Euclidean distance of every pixel to the nearest background
+// pixel. Background pixels have distance 0; everything outside the image
+// counts as background.
+type DistanceMap struct {
+	Rect image.Rectangle
+	Dist []float64 // row-major, len = Rect.Dx() * Rect.Dy()
+}
+
+// At returns the distance at (x, y), or 0 outside the map.
+func (m *DistanceMap) At(x, y int) float64 {
+	if !image.Pt(x, y).In(m.Rect) {
+		return 0
+	}
+	return m.Dist[(y-m.Rect.Min.Y)*m.Rect.Dx()+(x-m.Rect.Min.X)]
+}
+
+// Max returns the largest distance in the map.
+func (m *DistanceMap) Max() float64 {
+	var v float64
+	for _, d := range m.Dist {
+		v = math.Max(v, d)
+	}
+	return v
+}
+
+// Image renders the map as grayscale with the largest distance white.
+func (m *DistanceMap) Image() image.Image {
+	out := image.NewGray(m.Rect)
+	top := m.Max()
+	if top == 0 {
+		return out
+	}
+	w := m.Rect.Dx()
+	for i, d := range m.Dist {
+		out.Pix[(i/w)*out.Stride+i%w] = uint8(d * 255 / top)
+	}
+	return out
+}
+
+// DistanceTransform computes the exact Euclidean distance transform of the
+// black pixels of a binary image (Felzenszwalb-Huttenlocher).
+func DistanceTransform(ctx context.Context, bin image.Image) (*DistanceMap, error) {
+	bounds := bin.Bounds()
+	w, h := bounds.Dx(), bounds.Dy()
+	const inf = 1e20
+
+	// squared distances, object pixels start at "infinity"
+	sq := make([]float64, w*h)
+	for y := 0; y < h; y++ {
+		for x := 0; x < w; x++ {
+			if isBlack(bin, bounds.Min.X+x, bounds.Min.Y+y) {
+				sq[y*w+x] = inf
+			}
+		}
+	}
+
+	// 1-D transforms along columns, then rows. Each line gets a background
+	// sample on both ends so the image frame counts as background.
+	n := max(w, h) + 2
+	f, d := make([]float64, n), make([]float64, n)
+	v, z := make([]int, n), make([]float64, n+1)
+
+	for x := 0; x < w; x++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		f[0], f[h+1] = 0, 0
+		for y := 0; y < h; y++ {
+			f[y+1] = sq[y*w+x]
+		}
+		edt1d(f[:h+2], d[:h+2], v, z)
+		for y := 0; y < h; y++ {
+			sq[y*w+x] = d[y+1]
+		}
+	}
+	for y := 0; y < h; y++ {
+		if err := ctx.Err(); err != nil {
+			return nil, err
+		}
+		f[0], f[w+1] = 0, 0
+		copy(f[1:], sq[y*w:(y+1)*w])
+		edt1d(f[:w+2], d[:w+2], v, z)
+		copy(sq[y*w:(y+1)*w], d[1:w+1])
+	}
+
+	for i := range sq {
+		sq[i] = math.Sqrt(sq[i])
+	}
+	return &DistanceMap{Rect: bounds, Dist: sq}, nil
+}
+
+// edt1d computes the squared distance transform of the sampled function f
+// into d using the lower envelope of parabolas. v and z are scratch space.
+func edt1d(f, d []float64, v []int, z []float64) {
+	k := 0
+	v[0] = 0
+	z[0], z[1] = math.Inf(-1), math.Inf(1)
+	for q := 1; q < len(f); q++ {
+		s := ((f[q] + float64(q*q)) - (f[v[k]] + float64(v[k]*v[k]))) / float64(2*q-2*v[k])
+		for s <= z[k] {
+			k--
+			s = ((f[q] + float64(q*q)) - (f[v[k]] + float64(v[k]*v[k]))) / float64(2*q-2*v[k])
+		}
+		k++
+		v[k] = q
+		z[k] = s
+		z[k+1] = math.Inf(1)
+	}
+	k = 0
+	for q := range f {
+		for z[k+1] < float64(q) {
+			k++
+		}
+		dq := float64(q - v[k])
+		d[q] = dq*dq + f[v[k]]
+	}
+}
+
+// -----------------------------------------------------------------------------
+// Watershed splitting
+// -----------------------------------------------------------------------------
+
+// DefaultSplitDepth - minimum height of a distance-map peak above its saddle
+// for it to seed its own object.
+const DefaultSplitDepth = 2.0
+
+// SplitTouching separates touching blobs of a binary image with a
+// marker-controlled watershed on the distance transform. Peaks that rise at
+// least depth pixels above the saddle to a higher peak become markers; the
+// watershed lines between their basins are cleared to white, so each object
+// gets its own contour.
+func SplitTouching(ctx context.Context, bin image.Image, depth float64) (image.Image, error) {
+	dm, err := DistanceTransform(ctx, bin)
+	if err != nil {
+		return nil, err
+	}
+	bounds := dm.Rect
+	w, h := bounds.Dx(), bounds.Dy()
+	f := dm.Dist
+
+	neighbours := func(i int, fn func(j int)) {
+		x, y := i%w, i/w
+		for _, d := range dirs8 {
+			nx, ny := x+d.X, y+d.Y
+			if nx >= 0 && nx < w && ny >= 0 && ny < h {
+				fn(ny*w + nx)
+			}
+		}
+	}
+
+	// Step 1: h-maxima - reconstruct f-depth under f by dilation.
+	// Pixels where the reconstruction stays depth below f are peak tops.
+	rec := make([]float64, len(f))
+	pq := &pixelQueue{}
+	for i, v := range f {
+		rec[i] = math.Max(v-depth, 0)
+		heap.Push(pq, pixelItem{i, rec[i]})
+	}
+	for pq.Len() > 0 {
+		it := heap.Pop(pq).(pixelItem)
+		if it.prio < rec[it.idx] {
+			continue // stale entry
+		}
+		neighbours(it.idx, func(j int) {
+			if v := math.Min(rec[it.idx], f[j]); v > rec[j] {
+				rec[j] = v
+				heap.Push(pq, pixelItem{j, v})
+			}
+		})
+	}
+	if err := ctx.Err(); err != nil {
+		return nil, err
+	}
+
+	// Step 2: label connected peak tops as markers.
+	labels := make([]int, len(f))
+	next := 0
+	for i := range f {
+		if f[i] == 0 || labels[i] != 0 || f[i]-rec[i] < depth-1e-9 {
+			continue
+		}
+		next++
+		labels[i] = next
+		stack := []int{i}
+		for len(stack) > 0 {
+			p := stack[len(stack)-1]
+			stack = stack[:len(stack)-1]
+			neighbours(p, func(j int) {
+				if labels[j] == 0 && f[j] > 0 && f[j]-rec[j] >= depth-1e-9 {
+					labels[j] = next
+					stack = append(stack, j)
+				}
+			})
+		}
+	}
+
+	// Step 3: flood from the markers in order of decreasing distance.
+	// A pixel touching two different basins becomes a watershed line (-1).
+	queued := make([]bool, len(f))
+	pq = &pixelQueue{}
+	for i, l := range labels {
+		if l <= 0 {
+			continue
+		}
+		neighbours(i, func(j int) {
+			if labels[j] == 0 && f[j] > 0 && !queued[j] {
+				queued[j] = true
+				heap.Push(pq, pixelItem{j, f[j]})
+			}
+		})
+	}
+	for pq.Len() > 0 {
+		if pq.Len()%4096 == 0 {
+			if err := ctx.Err(); err != nil {
+				return nil, err
+			}
+		}
+		p := heap.Pop(pq).(pixelItem).idx
+		lbl := 0
+		neighbours(p, func(j int) {
+			switch l := labels[j]; {
+			case l <= 0:
+			case lbl == 0:
+				lbl = l
+			case lbl != l:
+				lbl = -1
+			}
+		})
+		labels[p] = lbl
+		if lbl < 0 {
+			continue
+		}
+		neighbours(p, func(j int) {
+			if labels[j] == 0 && f[j] > 0 && !queued[j] {
+				queued[j] = true
+				heap.Push(pq, pixelItem{j, f[j]})
+			}
+		})
+	}
+
+	// Step 4: object pixels stay black except on watershed lines.
+	out := image.NewGray(bounds)
+	for i, d := range f {
+		v := uint8(255)
+		if d > 0 && labels[i] >= 0 {
+			v = 0
+		}
+		out.SetGray(bounds.Min.X+i%w, bounds.Min.Y+i/w, color.Gray{Y: v})
+	}
+	return out, ctx.Err()
+}
+
+// pixelItem - pixel index with priority, for pixelQueue.
+type pixelItem struct {
+	idx  int
+	prio float64
+}
+
+// pixelQueue - max-heap of pixels by priority.
+type pixelQueue []pixelItem
+
+func (q pixelQueue) Len() int           { return len(q) }
+func (q pixelQueue) Less(i, j int) bool { return q[i].prio > q[j].prio }
+func (q pixelQueue) Swap(i, j int)      { q[i], q[j] = q[j], q[i] }
+func (q *pixelQueue) Push(x any)        { *q = append(*q, x.(pixelItem)) }
+func (q *pixelQueue) Pop() any {
+	old := *q
+	it := old[len(old)-1]
+	*q = old[:len(old)-1]
+	return it
+}
@@ -0,0 +1,79 @@
+package imageutil
+
+import (
+	"context"
+	"image"
+	"image/color"
+	"math"
+	"testing"
+)
+
+// touchingDiscs returns two black discs of radius 12 overlapping slightly.
+func touchingDiscs() *image.Gray {
+	img := image.NewGray(image.Rect(0, 0, 60, 30))
+	for y := 0; y < 30; y++ {
+		for x := 0; x < 60; x++ {
+			v := uint8(255)
+			if math.Hypot(float64(x-17), float64(y-15)) <= 12 || math.Hypot(float64(x-40), float64(y-15)) <= 12 {
+				v = 0
+			}
+			img.SetGray(x, y, color.Gray{Y: v})
+		}
+	}
+	return img
+}
+
+func TestDistanceTransform(t *testing.T) {
+	bin := binaryImage(
+		".......",
+		".#####.",
+		".#####.",
+		".#####.",
+		".#####.",
+		".#####.",
+		".......",
+	)
+	dm, err := DistanceTransform(context.Background(), bin)
+	if err != nil {
+		t.Fatalf("got error while computing distance transform:\n%s", err.Error())
+	}
+	for _, c := range []struct {
+		x, y int
+		want float64
+	}{
+		{0, 0, 0}, {1, 1, 1}, {2, 2, 2}, {3, 3, 3}, {2, 3, 2}, {5, 3, 1},
+	} {
+		if got := dm.At(c.x, c.y); math.Abs(got-c.want) > 1e-9 {
+			t.Fatalf("distance at (%d,%d): got %v, want %v", c.x, c.y, got, c.want)
+		}
+	}
+}
+
+func TestSplitTouching(t *testing.T) {
+	ctx := context.Background()
+	count := func(bin image.Image) int {
+		contours, err := FindContours(ctx, bin)
+		if err != nil {
+			t.Fatalf("got error while tracing contours:\n%s", err.Error())
+		}
+		n := 0
+		for _, c := range contours {
+			if !c.Hole {
+				n++
+			}
+		}
+		return n
+	}
+
+	bin := touchingDiscs()
+	if n := count(bin); n != 1 {
+		t.Fatalf("test image should be one merged blob, got %d", n)
+	}
+	split, err := SplitTouching(ctx, bin, DefaultSplitDepth)
+	if err != nil {
+		t.Fatalf("got error while splitting:\n%s", err.Error())
+	}
+	if n := count(split); n != 2 {
+		t.Fatalf("got %d objects after splitting, want 2", n)
+	}
+}